@@ -2,17 +2,23 @@ package automater
 
 import (
 	"context"
+	"fmt"
 	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/service/blackoutsrv"
+	"github.com/NubeIO/rubix-automater/automater/service/deadlettersrv"
 	"github.com/NubeIO/rubix-automater/automater/service/jobsrv"
+	"github.com/NubeIO/rubix-automater/automater/service/pausesrv"
 	"github.com/NubeIO/rubix-automater/automater/service/pipelinesrv"
 	"github.com/NubeIO/rubix-automater/automater/service/resultsrv"
 	"github.com/NubeIO/rubix-automater/automater/service/schedulersrv"
 	"github.com/NubeIO/rubix-automater/automater/service/tasksrv"
+	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
 	"github.com/NubeIO/rubix-automater/automater/service/worksrv"
 	"github.com/NubeIO/rubix-automater/automater/setup"
 	"github.com/NubeIO/rubix-automater/pkg/config"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -62,29 +68,49 @@ func (v *autoMater) Run() {
 	}
 	jobQueue := setup.JobQueueFactory(cfg.JobQueue, cfg.LoggingFormat)
 	v.logger.Infof("initialized [%s] as a job queue", cfg.JobQueue.Option)
-	storage := setup.StorageFactory(cfg.Storage)
+	storage, err := setup.StorageFactory(cfg.Storage)
+	if err != nil {
+		v.logger.Fatalf("could not initialize [%s] as a storage: %s", cfg.Storage.Option, err)
+	}
 	v.logger.Infof("initialized [%s] as a storage", cfg.Storage.Option)
-	pipelineService := pipelinesrv.New(storage, taskRepo, uuid.New(), ttime.New())
-	jobService := jobsrv.New(storage, taskRepo, uuid.New(), ttime.New())
-	resultService := resultsrv.New(storage)
+	// The services wake the scheduler up when they store some work getting due.
+	notifier := wakeup.New()
 
 	workPoolLogger := logger.NewLogger("workerpool", cfg.LoggingFormat)
 	workService := worksrv.New(
-		storage, taskRepo, ttime.New(), cfg.TimeoutUnit,
-		cfg.WorkerPool.Workers, cfg.WorkerPool.QueueCapacity, workPoolLogger)
+		storage, jobQueue, notifier, taskRepo, ttime.New(), cfg.TimeoutUnit,
+		cfg.WorkerPool.Workers, cfg.WorkerPool.QueueCapacity, cfg.WorkerPool.Queues, workPoolLogger)
 	workService.Start()
 
+	pipelineService := pipelinesrv.New(storage, notifier, workService, taskRepo, uuid.New(), ttime.New())
+	jobService := jobsrv.New(storage, notifier, workService, taskRepo, uuid.New(), ttime.New())
+	resultService := resultsrv.New(storage)
+	deadLetterService := deadlettersrv.New(jobQueue, storage, notifier)
+	blackoutService := blackoutsrv.New(storage, uuid.New(), ttime.New())
+	pauseService := pausesrv.New(storage, notifier, workService, ttime.New())
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// The leases of the jobs held by this instance tell the other instances and the next runs it's alive.
+	hostname, _ := os.Hostname()
+	instanceID, _ := uuid.New().Make("ins")
+	instance := fmt.Sprintf("%s-%s", hostname, instanceID)
+	heartbeat := time.Duration(cfg.WorkerPool.HeartbeatInterval) * cfg.TimeoutUnit
+	workService.Recover(ctx, instance, heartbeat)
+
 	schedulerLogger := logger.NewLogger("scheduler", cfg.LoggingFormat)
-	schedulerService := schedulersrv.New(jobQueue, storage, workService, ttime.New(), schedulerLogger)
+	schedulerService := schedulersrv.New(
+		jobQueue, storage, workService, notifier, ttime.New(),
+		time.Duration(cfg.Scheduler.MisfireGraceTime)*cfg.TimeoutUnit, schedulerLogger)
+	// The instances sharing the storage elect the one scheduling the due jobs, they all serve the job queue.
+	schedulerService.Lead(ctx, instance, heartbeat)
 	schedulerService.Schedule(ctx, time.Duration(cfg.Scheduler.StoragePollingInterval)*cfg.TimeoutUnit)
 	schedulerService.Dispatch(ctx, time.Duration(cfg.Scheduler.JobQueuePollingInterval)*cfg.TimeoutUnit)
 
 	server := setup.ServerFactory(
 		cfg.Server, jobService, pipelineService, resultService,
-		v.taskService, jobQueue, storage, cfg.LoggingFormat, v.logger)
+		v.taskService, deadLetterService, blackoutService, pauseService, jobQueue, storage, cfg.LoggingFormat, v.logger)
 	server.Serve()
 	v.logger.Infof("initialized [%s] server", cfg.Server.Protocol)
 
@@ -93,8 +119,10 @@ func (v *autoMater) Run() {
 	v.logger.Printf("server notified %+v", sig)
 	server.GracefullyStop()
 
-	jobQueue.Close()
+	// Stop feeding the worker pool and let the ongoing work get acknowledged before closing the queue.
+	cancel()
 	workService.Stop()
+	jobQueue.Close()
 	storage.Close()
 }
 
@@ -107,6 +135,14 @@ func (v *autoMater) RegisterTask(name string, callback func(...interface{}) (int
 	v.taskService.Register(name, callback)
 }
 
+// RegisterTaskWithContext registers a context aware task callback to the tasks database under the specified name, the
+// callback gets the context of the run and a typed input instead of the variadic args.
+func (v *autoMater) RegisterTaskWithContext(
+	name string, callback func(context.Context, taskRepo.TaskInput) (interface{}, error)) {
+
+	v.taskService.RegisterWithContext(name, callback)
+}
+
 // DecodeTaskParams uses https://github.com/mitchellh/mapstructure
 // to decode tasks params to a pointer of map or struct.
 func DecodeTaskParams(args []interface{}, params interface{}) {
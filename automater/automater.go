@@ -14,6 +14,8 @@ type Storage interface {
 	GetJob(uuid string) (*model.Job, error)
 	GetJobs(status model.JobStatus) ([]*model.Job, error)
 	GetDueJobs() ([]*model.Job, error)
+	// GetNextRunAt returns the earliest run_at of the pending jobs due from the given time, nil if there's none.
+	GetNextRunAt(after time.Time) (*time.Time, error)
 	GetJobsByPipelineID(pipelineID string) ([]*model.Job, error)
 	UpdateJob(uuid string, j *model.Job) (*model.Job, error)
 	Recycle(uuid string, body *model.Job) (*model.Job, error)
@@ -33,6 +35,34 @@ type Storage interface {
 	DeletePipeline(uuid string) error
 	RecyclePipeline(uuid string, p *model.Pipeline) (*model.Pipeline, error)
 
+	CreateBlackout(b *model.Blackout) error
+	GetBlackout(uuid string) (*model.Blackout, error)
+	// GetBlackouts fetches all blackouts ordered by creation time.
+	GetBlackouts() ([]*model.Blackout, error)
+	UpdateBlackout(uuid string, b *model.Blackout) error
+	DeleteBlackout(uuid string) error
+
+	// GetPause fetches the paused state of the scheduler, nothing is paused if it was never stored.
+	GetPause() (*model.Pause, error)
+	// UpdatePause atomically applies the update to the paused state of the scheduler and stores it, so concurrent
+	// updates don't overwrite each other. It returns the updated state.
+	UpdatePause(update func(p *model.Pause)) (*model.Pause, error)
+
+	// RenewLeases claims or extends the leases of the jobs for the owner until expiresAt.
+	RenewLeases(owner string, jobIDs []string, expiresAt time.Time) error
+	// GetLeases fetches the leases of the jobs by job uuid, expired ones included, the jobs without a lease are left out.
+	GetLeases(jobIDs []string) (map[string]*model.Lease, error)
+	ReleaseLeases(jobIDs []string) error
+	// ClaimLease atomically claims the lease of the job for the owner until expiresAt, unless another owner holds
+	// an unexpired lease or token isn't the fencing token of the current leadership. A zero token skips the check.
+	ClaimLease(owner, jobID string, token int64, expiresAt time.Time) (bool, error)
+
+	// AcquireLeadership atomically claims or extends the scheduler leadership for the owner until expiresAt, it
+	// returns the fencing token of the leadership and false if another owner holds it.
+	AcquireLeadership(owner string, expiresAt time.Time) (int64, bool, error)
+	// ReleaseLeadership gives the scheduler leadership up if the owner holds it.
+	ReleaseLeadership(owner string) error
+
 	CheckHealth() bool
 	Close() error
 
@@ -48,6 +78,19 @@ type JobQueue interface {
 	Push(j *model.Job) error
 	// Pop removes and returns the head job from the queue.
 	Pop() *model.Job
+	// Ack acknowledges that the popped job has been processed, so it's not redelivered.
+	Ack(j *model.Job) error
+
+	// PushDeadLetter adds a job message that can't be processed any further to the dead-letter store.
+	PushDeadLetter(d *model.DeadLetter) error
+	// GetDeadLetters fetches all dead letters, oldest first.
+	GetDeadLetters() ([]*model.DeadLetter, error)
+	// GetDeadLetter fetches a dead letter.
+	GetDeadLetter(uuid string) (*model.DeadLetter, error)
+	// DeleteDeadLetter deletes a dead letter.
+	DeleteDeadLetter(uuid string) error
+	// PurgeDeadLetters deletes all dead letters.
+	PurgeDeadLetters() error
 
 	// CheckHealth checks if the job queue is alive.
 	CheckHealth() bool
@@ -58,19 +101,63 @@ type JobQueue interface {
 
 // JobService represents a driver actor server interface.
 type JobService interface {
-	Create(name, taskName, subTaskName, description string, ScheduleAt string, timeout int, disable bool, options *model.JobOptions, taskParams map[string]interface{}) (*model.Job, error)
+	Create(name, taskName, subTaskName, description string, ScheduleAt, queue string, timeout, priority int, disable bool, options *model.JobOptions, taskParams map[string]interface{}) (*model.Job, error)
 	Get(uuid string) (*model.Job, error)
 	GetJobs(status string) ([]*model.Job, error)
 	Update(uuid string, body *model.Job) (*model.Job, error)
 	Recycle(uuid string, body *model.Job) (*model.Job, error)
 	Delete(uuid string) error
 	Drop() error
+	// Cancel cancels a job waiting to run or running.
+	Cancel(uuid string) (*model.Job, error)
+	// Schedule previews the next runs of a job.
+	Schedule(uuid string, count int) ([]model.ScheduledRun, error)
+	// PreviewSchedule previews the runs of a schedule before creating a job.
+	PreviewSchedule(runAt, runOnInterval, cronExpr, timezone string, count int) ([]model.ScheduledRun, error)
 }
 
 type TransactionService interface {
 	GetTransactions(status string) ([]*model.Transaction, error)
 }
 
+// DeadLetterService represents a driver actor server interface.
+type DeadLetterService interface {
+	// GetDeadLetters fetches all dead letters.
+	GetDeadLetters() ([]*model.DeadLetter, error)
+	// Get fetches a dead letter.
+	Get(uuid string) (*model.DeadLetter, error)
+	// Requeue pushes the job of a dead letter back to the job queue and deletes the dead letter.
+	Requeue(uuid string) (*model.Job, error)
+	// Delete deletes a dead letter.
+	Delete(uuid string) error
+	// Purge deletes all dead letters.
+	Purge() error
+}
+
+// BlackoutService represents a driver actor server interface.
+type BlackoutService interface {
+	// Create creates a new blackout.
+	Create(name, description, timezone string, global bool, weekly []model.WeeklyRange, absolute []model.AbsoluteRange) (*model.Blackout, error)
+	// Get fetches a blackout.
+	Get(uuid string) (*model.Blackout, error)
+	// GetBlackouts fetches all blackouts.
+	GetBlackouts() ([]*model.Blackout, error)
+	// Update replaces the windows of a blackout.
+	Update(uuid string, body *model.Blackout) (*model.Blackout, error)
+	// Delete deletes a blackout.
+	Delete(uuid string) error
+}
+
+// PauseService represents a driver actor server interface.
+type PauseService interface {
+	// Get fetches the paused state of the scheduler.
+	Get() (*model.Pause, error)
+	// Pause pauses the scheduling and the dispatching of the queue, or of every queue if the queue is empty.
+	Pause(queue string) (*model.Pause, error)
+	// Resume resumes the queue, or lifts the global pause if the queue is empty.
+	Resume(queue string) (*model.Pause, error)
+}
+
 // ResultService represents a driver actor server interface.
 type ResultService interface {
 	// Get fetches a job result.
@@ -82,7 +169,7 @@ type ResultService interface {
 // PipelineService represents a driver actor server interface.
 type PipelineService interface {
 	// Create creates a new pipeline.
-	Create(name, description, scheduleAt string, pipelineOptions *model.PipelineOptions, jobs []*model.Job) (*model.Pipeline, error)
+	Create(name, description, scheduleAt, queue string, priority int, pipelineOptions *model.PipelineOptions, jobs []*model.Job, vars map[string]interface{}) (*model.Pipeline, error)
 	// Get fetches a pipeline.
 	Get(uuid string) (*model.Pipeline, error)
 	// GetPipelines fetches all pipelines, optionally filters the pipelines by status.
@@ -95,6 +182,8 @@ type PipelineService interface {
 	Delete(uuid string) error
 	RecycleJob(uuid string, body *model.Job) (*model.Job, error)
 	RecyclePipeline(uuid string, p *model.Pipeline) (*model.Pipeline, error)
+	// Cancel cancels a pipeline waiting to run or running.
+	Cancel(uuid string) (*model.Pipeline, error)
 }
 
 // WorkService represents a driver actor server interface.
@@ -105,12 +194,27 @@ type WorkService interface {
 	// Stop signals the workers to stop working gracefully.
 	Stop()
 
-	// Dispatch dispatches a work to the worker pool.
+	// Dispatch dispatches a work to the worker pool of its queue, it blocks until the backlog has some space.
 	Dispatch(w work.Work)
 
+	// TryDispatch dispatches a work to the worker pool of its queue, it returns false if the backlog is full.
+	TryDispatch(w work.Work) bool
+
 	// CreateWork creates and return a new Work instance.
 	CreateWork(j *model.Job) work.Work
 
+	// HasQueue reports whether a worker pool serves the queue, the jobs without a queue are on the default queue.
+	HasQueue(queue string) bool
+
+	// IsRunning reports whether a run of the job is in progress, on this instance or another one.
+	IsRunning(j *model.Job) bool
+
+	// IsRunningElsewhere reports whether another instance sharing the storage holds the job.
+	IsRunningElsewhere(j *model.Job) bool
+
+	// Cancel cancels the runs of the job in progress, it returns false if the job isn't running.
+	Cancel(j *model.Job, reason string) bool
+
 	// Exec executes a work.
 	Exec(ctx context.Context, w work.Work) error
 }
@@ -119,16 +223,27 @@ type WorkService interface {
 type TaskService interface {
 	// Register registers a new tasks in the tasks database.
 	Register(name string, taskFunc taskRepo.TaskFunc)
+	// RegisterWithContext registers a new context aware tasks in the tasks database.
+	RegisterWithContext(name string, taskFunc taskRepo.ContextTaskFunc)
 	// GetTaskRepository returns the tasks database.
 	GetTaskRepository() *taskRepo.TaskRepository
 }
 
+// Notifier wakes the scheduler up when some work gets due, so it doesn't wait for its next poll.
+type Notifier interface {
+	// NotifyDue tells that some work is due at runAt.
+	NotifyDue(runAt time.Time)
+	// NotifyQueued tells that some work got pushed to the job queue.
+	NotifyQueued()
+}
+
 // Scheduler represents a domain event listener.
 type Scheduler interface {
-	// Schedule polls the storage in given interval and schedules due jobs for execution.
+	// Schedule schedules the due jobs for execution as they get due, the storage is polled in the given interval
+	// in case some work got due without a notification.
 	Schedule(ctx context.Context, duration time.Duration)
 	// Dispatch listens to the job queue for messages, consumes them and
-	// dispatches the jobs for execution.
+	// dispatches the jobs for execution, the job queue is polled in the given interval.
 	Dispatch(ctx context.Context, duration time.Duration)
 }
 
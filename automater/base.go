@@ -1,18 +1,45 @@
 package automater
 
 import (
+	"fmt"
 	"github.com/NubeIO/rubix-automater/automater/model"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/schedule"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/timeconversion"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
 	"github.com/jmattheis/go-timemath"
 	"time"
 )
 
-func RunAt(runAt string) (time.Time, error) {
+// localTimeLayout is a schedule_at without an offset, it's read in the timezone of the job or pipeline.
+const localTimeLayout = "2006-01-02T15:04:05"
+
+// location returns the IANA timezone of a job or pipeline.
+func location(timezone string) (*time.Location, error) {
+	loc, err := schedule.LoadLocation(timezone)
+	if err != nil {
+		return nil, &apperrors.ParseTimeErr{Message: fmt.Sprintf("%s is not a valid timezone: %s", timezone, err)}
+	}
+	return loc, nil
+}
+
+// parseScheduleAt parses an RFC3339 timestamp, or a timestamp without an offset in the given timezone.
+func parseScheduleAt(scheduleAt string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, scheduleAt)
+	if err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(localTimeLayout, scheduleAt, loc)
+}
+
+func RunAt(runAt, timezone string) (time.Time, error) {
 	now := ttime.New().Now()
+	loc, err := location(timezone)
+	if err != nil {
+		return now, err
+	}
 	if runAt != "" {
-		timeChecked, err := time.Parse(time.RFC3339Nano, runAt)
+		timeChecked, err := parseScheduleAt(runAt, loc)
 		if err != nil {
 			nextRunTime, err := timeconversion.AdjustTime(ttime.New().Now(), runAt) // user can just pass in 15 sec on the HTTP POST RunAt
 			if err != nil {
@@ -31,6 +58,14 @@ func RunAt(runAt string) (time.Time, error) {
 
 func PipelineRunAt(scheduleAt string, p *model.PipelineOptions, index int) (time.Time, error) {
 	now := ttime.New().Now()
+	var timezone string
+	if p != nil {
+		timezone = p.Timezone
+	}
+	loc, err := location(timezone)
+	if err != nil {
+		return now, err
+	}
 	var addDelay bool
 	if p != nil && index > 0 {
 		if p.DelayBetweenTask <= 0 {
@@ -44,7 +79,7 @@ func PipelineRunAt(scheduleAt string, p *model.PipelineOptions, index int) (time
 		scheduleAt = "1s"
 	}
 	if scheduleAt != "" {
-		timeChecked, err := time.Parse(time.RFC3339Nano, scheduleAt)
+		timeChecked, err := parseScheduleAt(scheduleAt, loc)
 		if err != nil {
 			nextRunTime, err := timeconversion.AdjustTime(ttime.New().Now(), scheduleAt) // user can just pass in 15 sec on the HTTP POST RunAt
 			if addDelay {
@@ -63,3 +98,200 @@ func PipelineRunAt(scheduleAt string, p *model.PipelineOptions, index int) (time
 	}
 
 }
+
+// NextRunAt returns the next run of a recurring schedule, either a cron expression or a relative interval such as "15 min".
+// The next run is anchored to the previous run_at rather than to the completion time so the schedule doesn't drift,
+// runs that would already be in the past are skipped.
+// The schedule is evaluated in the IANA timezone, so a daily run at 02:00 stays at 02:00 local time across the DST
+// transitions.
+func NextRunAt(previous *time.Time, runOnInterval, cronExpr, timezone string) (time.Time, error) {
+	return nextRunAt(ttime.New().Now(), previous, runOnInterval, cronExpr, timezone)
+}
+
+func nextRunAt(now time.Time, previous *time.Time, runOnInterval, cronExpr, timezone string) (time.Time, error) {
+	r, err := newRecurrence(runOnInterval, cronExpr, timezone)
+	if err != nil {
+		return now, err
+	}
+	now = now.In(r.loc)
+	anchor := now
+	if previous != nil && !previous.IsZero() && previous.Before(now) {
+		anchor = previous.In(r.loc)
+	}
+	next := r.next(anchor)
+	if !next.After(now) {
+		if r.cron != nil {
+			next = r.next(now)
+		} else {
+			// Skip the missed runs, but stay on the original grid.
+			next = r.step(next, int(now.Sub(next)/r.interval))
+			for !next.After(now) {
+				next = r.step(next, 1)
+			}
+		}
+	}
+	if next.IsZero() {
+		return now, &apperrors.ParseTimeErr{Message: fmt.Sprintf("cron expression %s never runs", cronExpr)}
+	}
+	return next, nil
+}
+
+// recurrence is a recurring schedule, either a cron expression or a relative interval, in its timezone.
+type recurrence struct {
+	cron     interface{ Next(time.Time) time.Time }
+	interval time.Duration
+	loc      *time.Location
+}
+
+func newRecurrence(runOnInterval, cronExpr, timezone string) (*recurrence, error) {
+	loc, err := location(timezone)
+	if err != nil {
+		return nil, err
+	}
+	if cronExpr != "" {
+		s, err := schedule.Parse(cronExpr)
+		if err != nil {
+			return nil, &apperrors.ParseTimeErr{Message: err.Error()}
+		}
+		return &recurrence{cron: s, loc: loc}, nil
+	}
+	var zero time.Time
+	next, err := timeconversion.AdjustTime(zero, runOnInterval)
+	if err != nil {
+		return nil, &apperrors.ParseTimeErr{Message: err.Error()}
+	}
+	interval := next.Sub(zero)
+	if interval <= 0 {
+		return nil, &apperrors.ParseTimeErr{Message: fmt.Sprintf("interval %s should be positive", runOnInterval)}
+	}
+	return &recurrence{interval: interval, loc: loc}, nil
+}
+
+// next returns the run following t, whether it's in the past or not.
+func (r *recurrence) next(t time.Time) time.Time {
+	if r.cron != nil {
+		return r.cron.Next(t.In(r.loc))
+	}
+	return r.step(t.In(r.loc), 1)
+}
+
+// step moves n intervals from t.
+func (r *recurrence) step(t time.Time, n int) time.Time {
+	if r.interval%(24*time.Hour) == 0 {
+		// Whole days keep the wall clock, a day across a DST transition is 23 or 25 hours long.
+		return t.AddDate(0, 0, n*int(r.interval/(24*time.Hour)))
+	}
+	return t.Add(time.Duration(n) * r.interval)
+}
+
+// CronScheduleAt returns the first run of the cron expression when no schedule_at was given, an invalid expression or
+// timezone is left to the validation of the job options.
+func CronScheduleAt(scheduleAt, cronExpr, timezone string) string {
+	if scheduleAt != "" || cronExpr == "" {
+		return scheduleAt
+	}
+	loc, err := schedule.LoadLocation(timezone)
+	if err != nil {
+		return scheduleAt
+	}
+	next, err := schedule.Next(cronExpr, ttime.New().Now().In(loc))
+	if err != nil || next.IsZero() {
+		return scheduleAt
+	}
+	return next.Format(time.RFC3339Nano)
+}
+
+// RecycleRunAt moves the run_at of a recurring job to its next run, unless it's already scheduled in the future.
+func RecycleRunAt(j *model.Job) error {
+	anchor := j.ScheduleAnchor
+	j.ScheduleAnchor = nil
+	if !j.IsRecycleJob() {
+		return nil
+	}
+	if anchor != nil {
+		// The run was postponed, the schedule carries on from the run_at it was due at.
+		j.RunAt = anchor
+	}
+	if next := catchUpRunAt(&j.CatchUp, j.RunAt, j.JobOptions.RunOnInterval, j.JobOptions.Cron, j.JobOptions.Timezone); next != nil {
+		j.RunAt = next
+		return nil
+	}
+	if j.RunAt != nil && j.RunAt.After(ttime.New().Now()) {
+		return nil
+	}
+	next, err := NextRunAt(j.RunAt, j.JobOptions.RunOnInterval, j.JobOptions.Cron, j.JobOptions.Timezone)
+	if err != nil {
+		return err
+	}
+	j.RunAt = &next
+	return nil
+}
+
+// SkipRunAt moves a recurring job waiting for a run in the future on to the following run of its schedule, eg: the
+// upcoming run got cancelled. The run_at of a job due already is left to RecycleRunAt.
+func SkipRunAt(j *model.Job) error {
+	if !j.IsRecycleJob() || j.RunAt == nil || !j.RunAt.After(ttime.New().Now()) {
+		return nil
+	}
+	r, err := newRecurrence(j.JobOptions.RunOnInterval, j.JobOptions.Cron, j.JobOptions.Timezone)
+	if err != nil {
+		return err
+	}
+	runAt := j.RunAt
+	if j.ScheduleAnchor != nil {
+		runAt = j.ScheduleAnchor
+	}
+	next := r.next(*runAt)
+	for !next.After(*j.RunAt) {
+		next = r.next(next)
+	}
+	j.ScheduleAnchor = nil
+	j.RunAt = &next
+	return nil
+}
+
+// RecyclePipelineRunAts returns the run_at of each of the jobs of a recycled pipeline, the first job runs on the
+// next run of the pipeline schedule and the next ones follow with the delay between tasks. The steps of a graph all
+// start on the next run instead, except the ones waiting for other steps, which get no run_at until they're ready.
+func RecyclePipelineRunAts(p *model.Pipeline, jobs []*model.Job) ([]*time.Time, error) {
+	var first time.Time
+	var err error
+	runAt := p.RunAt
+	if p.ScheduleAnchor != nil {
+		// The run was postponed, the schedule carries on from the run_at it was due at.
+		runAt = p.ScheduleAnchor
+	}
+	p.ScheduleAnchor = nil
+	options := p.PipelineOptions
+	if options != nil && (options.Cron != "" || options.RunOnInterval != "") {
+		if next := catchUpRunAt(&p.CatchUp, runAt, options.RunOnInterval, options.Cron, options.Timezone); next != nil {
+			first = *next
+		} else {
+			first, err = NextRunAt(runAt, options.RunOnInterval, options.Cron, options.Timezone)
+		}
+	} else {
+		first, err = PipelineRunAt("", options, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dag := model.IsDAG(jobs)
+	upstreams := model.Upstreams(jobs)
+	runAts := make([]*time.Time, len(jobs))
+	for i, j := range jobs {
+		if len(upstreams[j.Name]) > 0 {
+			continue
+		}
+		runAt := first
+		if options != nil && i > 0 && !dag {
+			delay := options.DelayBetweenTask
+			if delay <= 0 {
+				delay = 1
+			}
+			runAt = timemath.Second.Add(runAt, delay*i)
+		}
+		runAt = runAt.Add(time.Millisecond * time.Duration(i+2)) // in db GetDueJobs it orders by time desc, so we need a small buffer (this is a hack)
+		runAts[i] = &runAt
+	}
+	return runAts, nil
+}
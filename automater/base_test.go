@@ -0,0 +1,164 @@
+package automater
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+func TestNextRunAt_AnchoredToPreviousRun(t *testing.T) {
+	now := time.Now()
+	previous := now.Add(-10*time.Minute - 30*time.Second)
+
+	next, err := NextRunAt(&previous, "15 min", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := previous.Add(15 * time.Minute); !next.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, next)
+	}
+
+	// The missed runs are skipped but the grid is kept.
+	previous = now.Add(-50 * time.Minute)
+	next, err = NextRunAt(&previous, "15 min", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := previous.Add(60 * time.Minute); !next.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, next)
+	}
+
+	next, err = NextRunAt(&previous, "", "*/15 * * * *", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.After(now) || next.Minute()%15 != 0 || next.Second() != 0 {
+		t.Errorf("expected the next quarter hour, got %s", next)
+	}
+
+	if _, err := NextRunAt(nil, "", "not a cron", ""); err == nil {
+		t.Error("expected an invalid cron expression to fail")
+	}
+	if _, err := NextRunAt(nil, "", "@daily", "Mars/Olympus_Mons"); err == nil {
+		t.Error("expected an invalid timezone to fail")
+	}
+}
+
+func TestNextRunAt_DaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The clocks go forward on 2022-03-13 at 02:00.
+	previous := time.Date(2022, 3, 12, 4, 0, 0, 0, loc)
+	now := previous.Add(time.Minute)
+	expected := time.Date(2022, 3, 13, 4, 0, 0, 0, loc)
+
+	for _, test := range []struct{ interval, cron string }{{"1 day", ""}, {"", "0 4 * * *"}} {
+		next, err := nextRunAt(now, &previous, test.interval, test.cron, "America/New_York")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.Equal(expected) {
+			t.Errorf("%q %q: expected %s, got %s", test.interval, test.cron, expected, next)
+		}
+		if next.Sub(previous) != 23*time.Hour {
+			t.Errorf("%q %q: expected a 23 hours day, got %s", test.interval, test.cron, next.Sub(previous))
+		}
+	}
+}
+
+func TestRunAt_LocalTime(t *testing.T) {
+	runAt, err := RunAt("2022-06-04T02:00:00", "Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2022, 6, 3, 16, 0, 0, 0, time.UTC); !runAt.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, runAt)
+	}
+}
+
+func TestRecycleRunAt_CatchUp(t *testing.T) {
+	runAt := time.Now().Add(-3*time.Minute - 30*time.Second)
+	j := &model.Job{
+		RunAt:      &runAt,
+		CatchUp:    3,
+		JobOptions: &model.JobOptions{EnableInterval: true, RunOnInterval: "1 min"},
+	}
+	for i := 1; i <= 2; i++ {
+		if err := RecycleRunAt(j); err != nil {
+			t.Fatal(err)
+		}
+		if expected := runAt.Add(time.Duration(i) * time.Minute); !j.RunAt.Equal(expected) {
+			t.Fatalf("catch up %d: expected %s, got %s", i, expected, j.RunAt)
+		}
+	}
+	if err := RecycleRunAt(j); err != nil {
+		t.Fatal(err)
+	}
+	if j.CatchUp != 0 || !j.RunAt.After(time.Now()) {
+		t.Fatalf("expected the next run once caught up, got %s with %d left", j.RunAt, j.CatchUp)
+	}
+}
+
+func TestRecycleRunAt_AfterRetry(t *testing.T) {
+	runAt := time.Now().Add(-5 * time.Minute)
+	j := &model.Job{
+		RunAt:      &runAt,
+		JobOptions: &model.JobOptions{EnableInterval: true, RunOnInterval: "15 min"},
+	}
+	retryAt := runAt.Add(47 * time.Second)
+	j.MarkRetry(&retryAt)
+	if err := RecycleRunAt(j); err != nil {
+		t.Fatal(err)
+	}
+	if expected := runAt.Add(15 * time.Minute); !j.RunAt.Equal(expected) || j.ScheduleAnchor != nil {
+		t.Fatalf("expected the schedule to carry on from %s, got %s", expected, j.RunAt)
+	}
+}
+
+func TestSkipRunAt(t *testing.T) {
+	runAt := time.Now().Add(5 * time.Minute)
+	j := &model.Job{
+		RunAt:      &runAt,
+		JobOptions: &model.JobOptions{EnableInterval: true, RunOnInterval: "15 min"},
+	}
+	if err := SkipRunAt(j); err != nil {
+		t.Fatal(err)
+	}
+	if expected := runAt.Add(15 * time.Minute); !j.RunAt.Equal(expected) {
+		t.Fatalf("expected the upcoming run to be skipped for %s, got %s", expected, j.RunAt)
+	}
+}
+
+func TestRecyclePipelineRunAts_AfterBlackout(t *testing.T) {
+	runAt := time.Now().Add(-5 * time.Minute)
+	p := &model.Pipeline{RunAt: &runAt, PipelineOptions: &model.PipelineOptions{RunOnInterval: "15 min"}}
+	end := runAt.Add(3 * time.Minute)
+	p.Postpone(&end)
+	runAts, err := RecyclePipelineRunAts(p, []*model.Job{{Name: "ping"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := runAt.Add(15 * time.Minute)
+	if offset := runAts[0].Sub(expected); offset < 0 || offset >= time.Second || p.ScheduleAnchor != nil {
+		t.Fatalf("expected the schedule to carry on from %s, got %s", expected, runAts[0])
+	}
+}
+
+func TestRecyclePipelineRunAts_DelayBetweenTask(t *testing.T) {
+	runAt := time.Now().Add(-30 * time.Second)
+	p := &model.Pipeline{RunAt: &runAt, PipelineOptions: &model.PipelineOptions{RunOnInterval: "1 min"}}
+	jobs := []*model.Job{{Name: "ping"}, {Name: "install"}}
+	runAts, err := RecyclePipelineRunAts(p, jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delay := runAts[1].Sub(*runAts[0]); delay < time.Second || delay > 2*time.Second {
+		t.Fatalf("expected the second job a second after the first, got %s", delay)
+	}
+	if p.PipelineOptions.DelayBetweenTask != 0 {
+		t.Fatalf("expected the pipeline options to be left as is, got a delay of %d", p.PipelineOptions.DelayBetweenTask)
+	}
+}
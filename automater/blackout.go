@@ -0,0 +1,57 @@
+package automater
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+// CheckBlackouts checks that the blackouts attached to a job or pipeline exist, by name or uuid.
+func CheckBlackouts(storage Storage, attached []string) error {
+	if len(attached) == 0 {
+		return nil
+	}
+	blackouts, err := storage.GetBlackouts()
+	if err != nil {
+		return err
+	}
+	for _, name := range attached {
+		found := false
+		for _, b := range blackouts {
+			if name == b.Name || name == b.UUID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &apperrors.ResourceValidationErr{Message: fmt.Sprintf("blackout %s does not exist", name)}
+		}
+	}
+	return nil
+}
+
+// ActiveBlackout returns the blackout deferring the work attached to the given blackouts at t, along with the end
+// of the window. The windows of different blackouts following each other are followed to the end of the last one.
+func ActiveBlackout(blackouts []*model.Blackout, attached []string, t time.Time) (*model.Blackout, time.Time, bool) {
+	var active *model.Blackout
+	end := t
+	for {
+		var next *model.Blackout
+		nextEnd := end
+		for _, b := range blackouts {
+			if !b.AppliesTo(attached) {
+				continue
+			}
+			if until, ok := b.ActiveUntil(end); ok && until.After(nextEnd) {
+				next, nextEnd = b, until
+			}
+		}
+		if next == nil {
+			break
+		}
+		active, end = next, nextEnd
+	}
+	return active, end, active != nil
+}
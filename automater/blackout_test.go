@@ -0,0 +1,110 @@
+package automater
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+func TestActiveBlackout(t *testing.T) {
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+	holidayStart := time.Date(2026, 12, 24, 0, 0, 0, 0, sydney)
+	holidayEnd := time.Date(2026, 12, 27, 0, 0, 0, 0, sydney)
+	blackouts := []*model.Blackout{
+		{
+			UUID:     "blk_hours",
+			Name:     "business-hours",
+			Timezone: "Australia/Sydney",
+			Weekly:   []model.WeeklyRange{{Days: []string{"mon", "Tuesday"}, Start: "08:00", End: "17:00"}},
+		},
+		{
+			UUID:     "blk_nights",
+			Name:     "nights",
+			Timezone: "Australia/Sydney",
+			Weekly:   []model.WeeklyRange{{Days: []string{"monday"}, Start: "17:00", End: "01:00"}},
+		},
+		{
+			UUID:     "blk_holidays",
+			Name:     "holidays",
+			Global:   true,
+			Absolute: []model.AbsoluteRange{{Start: &holidayStart, End: &holidayEnd}},
+		},
+	}
+	for _, b := range blackouts {
+		if err := b.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		attached []string
+		at       time.Time
+		active   string
+		end      time.Time
+	}{
+		{
+			name:     "not attached",
+			attached: nil,
+			at:       time.Date(2026, 10, 19, 9, 0, 0, 0, sydney),
+		},
+		{
+			name:     "outside the window",
+			attached: []string{"business-hours"},
+			at:       time.Date(2026, 10, 21, 9, 0, 0, 0, sydney),
+		},
+		{
+			name:     "in the window",
+			attached: []string{"business-hours"},
+			at:       time.Date(2026, 10, 20, 9, 0, 0, 0, sydney),
+			active:   "business-hours",
+			end:      time.Date(2026, 10, 20, 17, 0, 0, 0, sydney),
+		},
+		{
+			name:     "attached by uuid",
+			attached: []string{"blk_hours"},
+			at:       time.Date(2026, 10, 20, 9, 0, 0, 0, sydney),
+			active:   "business-hours",
+			end:      time.Date(2026, 10, 20, 17, 0, 0, 0, sydney),
+		},
+		{
+			name:     "windows following each other",
+			attached: []string{"business-hours", "nights"},
+			at:       time.Date(2026, 10, 19, 9, 0, 0, 0, sydney),
+			active:   "nights",
+			end:      time.Date(2026, 10, 20, 1, 0, 0, 0, sydney),
+		},
+		{
+			name:     "window spanning midnight",
+			attached: []string{"nights"},
+			at:       time.Date(2026, 10, 20, 0, 30, 0, 0, sydney),
+			active:   "nights",
+			end:      time.Date(2026, 10, 20, 1, 0, 0, 0, sydney),
+		},
+		{
+			name:     "global",
+			attached: nil,
+			at:       time.Date(2026, 12, 25, 9, 0, 0, 0, sydney),
+			active:   "holidays",
+			end:      holidayEnd,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, end, ok := ActiveBlackout(blackouts, test.attached, test.at)
+			if test.active == "" {
+				if ok {
+					t.Fatalf("expected no active blackout, got %s", b.Name)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected blackout %s to be active", test.active)
+			}
+			if b.Name != test.active || !end.Equal(test.end) {
+				t.Fatalf("expected %s until %s, got %s until %s", test.active, test.end, b.Name, end)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+	"github.com/go-redis/redis/v8"
+)
+
+// The dead letters are kept in a hash by uuid.
+const deadLettersKey = "job-queue:dead-letters"
+
+// newDecodeDeadLetter returns the dead letter of a message that could not be decoded.
+func newDecodeDeadLetter(payload string, err error) *model.DeadLetter {
+	id, _ := uuid.New().Make("dlq")
+	now := ttime.New().Now()
+	return model.NewDeadLetter(id, nil, payload, fmt.Sprintf("could not decode job message: %s", err), &now)
+}
+
+// PushDeadLetter adds a job message that can't be processed any further to the dead-letter store.
+func (q *redisQueue) PushDeadLetter(d *model.DeadLetter) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return q.HSet(ctx, q.GetRedisPrefixedKey(deadLettersKey), d.UUID, value).Err()
+}
+
+// GetDeadLetters fetches all dead letters, oldest first.
+func (q *redisQueue) GetDeadLetters() ([]*model.DeadLetter, error) {
+	values, err := q.HVals(ctx, q.GetRedisPrefixedKey(deadLettersKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]*model.DeadLetter, 0, len(values))
+	for _, value := range values {
+		d := &model.DeadLetter{}
+		if err := json.Unmarshal([]byte(value), d); err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, d)
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].CreatedAt.Before(*deadLetters[j].CreatedAt)
+	})
+	return deadLetters, nil
+}
+
+// GetDeadLetter fetches a dead letter.
+func (q *redisQueue) GetDeadLetter(uuid string) (*model.DeadLetter, error) {
+	value, err := q.HGet(ctx, q.GetRedisPrefixedKey(deadLettersKey), uuid).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "dead letter"}
+		}
+		return nil, err
+	}
+	var d *model.DeadLetter
+	if err := json.Unmarshal(value, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// DeleteDeadLetter deletes a dead letter.
+func (q *redisQueue) DeleteDeadLetter(uuid string) error {
+	return q.HDel(ctx, q.GetRedisPrefixedKey(deadLettersKey), uuid).Err()
+}
+
+// PurgeDeadLetters deletes all dead letters.
+func (q *redisQueue) PurgeDeadLetters() error {
+	return q.Del(ctx, q.GetRedisPrefixedKey(deadLettersKey)).Err()
+}
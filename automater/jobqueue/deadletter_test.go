@@ -0,0 +1,38 @@
+package jobqueue
+
+import (
+	"testing"
+
+	"github.com/NubeIO/rubix-automater/pkg/config"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisQueue_DeadLettersUndecodableMessages(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := config.Redis{URL: "redis://" + mr.Addr(), KeyPrefix: "automater", PoolSize: 2, MinIdleConns: 1}
+	q := NewRedisQueue(cfg, "text")
+	defer q.Close()
+
+	mr.Lpush("automater:job-queue", "{not json")
+	if j := q.Pop(); j != nil {
+		t.Fatalf("expected no job, got %v", j)
+	}
+	deadLetters, err := q.GetDeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Payload != "{not json" || deadLetters[0].Job != nil {
+		t.Fatalf("expected the raw payload to be dead-lettered, got %v", deadLetters)
+	}
+
+	d, err := q.GetDeadLetter(deadLetters[0].UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.DeleteDeadLetter(d.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetDeadLetter(d.UUID); err == nil {
+		t.Fatal("expected deleted dead letter not to be found")
+	}
+}
@@ -0,0 +1,138 @@
+package jobqueue
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+var _ automater.JobQueue = &memoryQueue{}
+
+type memoryQueue struct {
+	mu       sync.RWMutex
+	capacity int
+	size     int
+	// A FIFO per priority, indexed by priority.
+	queues      [model.MaxPriority + 1][]*model.Job
+	closed      bool
+	deadLetters map[string]*model.DeadLetter
+	logger      *logrus.Logger
+}
+
+// NewMemoryQueue returns a bounded in-process priority queue, jobs are lost on restart.
+func NewMemoryQueue(capacity int, loggingFormat string) *memoryQueue {
+	return &memoryQueue{
+		capacity:    capacity,
+		deadLetters: make(map[string]*model.DeadLetter),
+		logger:      logger.NewLogger("memoryQueue", loggingFormat),
+	}
+}
+
+// Push adds a job to the queue, it returns a FullQueueErr if the capacity is reached and a ClosedQueueErr once the
+// queue is closed.
+func (q *memoryQueue) Push(j *model.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		q.logger.Errorf("could not push job %s: queue is closed", j.UUID)
+		return &apperrors.ClosedQueueErr{}
+	}
+	if q.size >= q.capacity {
+		return &apperrors.FullQueueErr{}
+	}
+	priority := model.ClampPriority(j.Priority)
+	q.queues[priority] = append(q.queues[priority], j)
+	q.size++
+	return nil
+}
+
+// Pop removes and returns the oldest job of the highest priority, nil if the queue is empty.
+func (q *memoryQueue) Pop() *model.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for priority := model.MaxPriority; priority >= model.MinPriority; priority-- {
+		if len(q.queues[priority]) == 0 {
+			continue
+		}
+		j := q.queues[priority][0]
+		q.queues[priority][0] = nil
+		q.queues[priority] = q.queues[priority][1:]
+		q.size--
+		return j
+	}
+	return nil
+}
+
+// Ack is a no-op, popped jobs are never redelivered.
+func (q *memoryQueue) Ack(j *model.Job) error {
+	return nil
+}
+
+// PushDeadLetter adds a job message that can't be processed any further to the dead-letter store.
+func (q *memoryQueue) PushDeadLetter(d *model.DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deadLetters[d.UUID] = d
+	return nil
+}
+
+// GetDeadLetters fetches all dead letters, oldest first.
+func (q *memoryQueue) GetDeadLetters() ([]*model.DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	deadLetters := make([]*model.DeadLetter, 0, len(q.deadLetters))
+	for _, d := range q.deadLetters {
+		deadLetters = append(deadLetters, d)
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].CreatedAt.Before(*deadLetters[j].CreatedAt)
+	})
+	return deadLetters, nil
+}
+
+// GetDeadLetter fetches a dead letter.
+func (q *memoryQueue) GetDeadLetter(uuid string) (*model.DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	d, ok := q.deadLetters[uuid]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "dead letter"}
+	}
+	return d, nil
+}
+
+// DeleteDeadLetter deletes a dead letter.
+func (q *memoryQueue) DeleteDeadLetter(uuid string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.deadLetters, uuid)
+	return nil
+}
+
+// PurgeDeadLetters deletes all dead letters.
+func (q *memoryQueue) PurgeDeadLetters() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deadLetters = make(map[string]*model.DeadLetter)
+	return nil
+}
+
+// CheckHealth checks if the job queue is alive.
+func (q *memoryQueue) CheckHealth() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return !q.closed
+}
+
+// Close liberates the bound resources of the job queue.
+func (q *memoryQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+}
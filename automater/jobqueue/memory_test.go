@@ -0,0 +1,54 @@
+package jobqueue
+
+import (
+	"testing"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	q := NewMemoryQueue(2, "text")
+	if j := q.Pop(); j != nil {
+		t.Fatalf("expected empty queue, got %v", j)
+	}
+	for _, uuid := range []string{"job_1", "job_2"} {
+		if err := q.Push(&model.Job{UUID: uuid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := q.Push(&model.Job{UUID: "job_3"})
+	if _, ok := err.(*apperrors.FullQueueErr); !ok {
+		t.Fatalf("expected FullQueueErr, got %v", err)
+	}
+	if j := q.Pop(); j == nil || j.UUID != "job_1" {
+		t.Fatalf("expected job_1 first, got %v", j)
+	}
+	if err := q.Push(&model.Job{UUID: "job_3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Close()
+	if q.CheckHealth() {
+		t.Fatal("expected closed queue to be unhealthy")
+	}
+	if err := q.Push(&model.Job{UUID: "job_4"}); err == nil {
+		t.Fatal("expected push to a closed queue to fail")
+	} else if _, ok := err.(*apperrors.ClosedQueueErr); !ok {
+		t.Fatalf("expected a closed queue error, got %v", err)
+	}
+}
+
+func TestMemoryQueue_Priority(t *testing.T) {
+	q := NewMemoryQueue(10, "text")
+	q.Push(&model.Job{UUID: "bulk_1"})
+	q.Push(&model.Job{UUID: "urgent_1", Priority: model.MaxPriority})
+	q.Push(&model.Job{UUID: "bulk_2"})
+	q.Push(&model.Job{UUID: "urgent_2", Priority: model.MaxPriority})
+
+	for _, expected := range []string{"urgent_1", "urgent_2", "bulk_1", "bulk_2"} {
+		if j := q.Pop(); j == nil || j.UUID != expected {
+			t.Fatalf("expected %s, got %v", expected, j)
+		}
+	}
+}
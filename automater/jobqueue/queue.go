@@ -3,6 +3,7 @@ package jobqueue
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/automater/model"
 	"github.com/NubeIO/rubix-automater/pkg/config"
@@ -30,8 +31,36 @@ func NewRedisQueue(cfg config.Redis, loggingFormat string) *redisQueue {
 	}
 }
 
+// queueKey returns the list of the priority, the default priority keeps the original job-queue key.
+func (q *redisQueue) queueKey(priority int) string {
+	if priority == model.MinPriority {
+		return q.GetRedisPrefixedKey("job-queue")
+	}
+	return q.GetRedisPrefixedKey(fmt.Sprintf("job-queue:priority:%d", priority))
+}
+
+// queueKeys returns the lists of all the priorities, most urgent first.
+func (q *redisQueue) queueKeys() []string {
+	keys := make([]string, 0, model.MaxPriority-model.MinPriority+1)
+	for priority := model.MaxPriority; priority >= model.MinPriority; priority-- {
+		keys = append(keys, q.queueKey(priority))
+	}
+	return keys
+}
+
+// popScript pops the tail of the first non-empty list.
+var popScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local payload = redis.call('RPOP', key)
+	if payload then
+		return payload
+	end
+end
+return false
+`)
+
 func (q *redisQueue) Push(j *model.Job) error {
-	key := q.GetRedisPrefixedKey("job-queue")
+	key := q.queueKey(model.ClampPriority(j.Priority))
 	value, err := json.Marshal(j)
 	if err != nil {
 		q.logger.Errorf("could not marshal job: %s", err)
@@ -45,9 +74,9 @@ func (q *redisQueue) Push(j *model.Job) error {
 	return nil
 }
 
+// Pop removes and returns the oldest job of the highest priority.
 func (q *redisQueue) Pop() *model.Job {
-	key := q.GetRedisPrefixedKey("job-queue")
-	val, err := q.RPop(ctx, key).Bytes()
+	val, err := popScript.Run(ctx, q.Client, q.queueKeys()).Text()
 	if err != nil {
 		if err != redis.Nil {
 			q.logger.Errorf("could not RPOP job message: %s", err)
@@ -55,14 +84,22 @@ func (q *redisQueue) Pop() *model.Job {
 		return nil
 	}
 	var j *model.Job
-	err = json.Unmarshal(val, &j)
+	err = json.Unmarshal([]byte(val), &j)
 	if err != nil {
 		q.logger.Errorf("could not unmarshal message body: %s", err)
+		if err := q.PushDeadLetter(newDecodeDeadLetter(val, err)); err != nil {
+			q.logger.Errorf("could not dead-letter message: %s", err)
+		}
 		return nil
 	}
 	return j
 }
 
+// Ack is a no-op, popped jobs are never redelivered.
+func (q *redisQueue) Ack(j *model.Job) error {
+	return nil
+}
+
 func (q *redisQueue) Close() {
 	q.Client.Close()
 }
@@ -0,0 +1,192 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/config"
+	"github.com/go-redis/redis/v8"
+)
+
+var _ automater.JobQueue = &reliableRedisQueue{}
+
+/*
+A popped job is moved atomically from its priority list to job-queue:processing and leased in
+job-queue:leases (ZSET scored by the lease deadline in unix ms). Ack removes both entries,
+the reaper moves entries whose lease expired back to the head of their priority list for redelivery.
+The same message can be in flight more than once, eg: a job pushed twice, its lease is kept until
+the last delivery is acked.
+*/
+
+// reliablePopScript moves the tail of the first non-empty list of KEYS[3:] to the processing
+// list KEYS[1] and leases it in KEYS[2] until ARGV[1].
+var reliablePopScript = redis.NewScript(`
+for i = 3, #KEYS do
+	local payload = redis.call('RPOP', KEYS[i])
+	if payload then
+		redis.call('LPUSH', KEYS[1], payload)
+		redis.call('ZADD', KEYS[2], ARGV[1], payload)
+		return payload
+	end
+end
+return false
+`)
+
+// ackScript removes a delivery of the payload ARGV[1] from the processing list KEYS[1], and its
+// lease from KEYS[2] once no other delivery of the payload is in flight.
+var ackScript = redis.NewScript(`
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+for _, payload in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	if payload == ARGV[1] then
+		return 0
+	end
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// reapScript requeues the processing entries whose lease expired before ARGV[1],
+// as well as processing entries without any lease, to the list of their priority.
+// ARGV[2] is the default job-queue key, the lists of the other priorities up to ARGV[3] are suffixed with :priority:<n>.
+var reapScript = redis.NewScript(`
+local requeued = 0
+for _, payload in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local deadline = redis.call('ZSCORE', KEYS[2], payload)
+	if not deadline or tonumber(deadline) <= tonumber(ARGV[1]) then
+		local key = ARGV[2]
+		local ok, j = pcall(cjson.decode, payload)
+		if ok and type(j) == 'table' and type(j['priority']) == 'number' and j['priority'] > 0 then
+			key = ARGV[2] .. ':priority:' .. string.format('%d', math.min(j['priority'], tonumber(ARGV[3])))
+		end
+		redis.call('LREM', KEYS[1], 1, payload)
+		redis.call('ZREM', KEYS[2], payload)
+		redis.call('RPUSH', key, payload)
+		requeued = requeued + 1
+	end
+end
+return requeued
+`)
+
+type reliableRedisQueue struct {
+	*redisQueue
+	visibilityTimeout time.Duration
+
+	mu sync.Mutex
+	// The raw payloads of the popped jobs by delivery, needed to ack them. A job popped twice is
+	// delivered as two distinct jobs.
+	inFlight map[*model.Job]string
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewReliableRedisQueue returns a redis queue that redelivers the popped jobs which are
+// not acknowledged within the visibility timeout.
+func NewReliableRedisQueue(cfg config.Redis, visibilityTimeout time.Duration, loggingFormat string) *reliableRedisQueue {
+	q := &reliableRedisQueue{
+		redisQueue:        NewRedisQueue(cfg, loggingFormat),
+		visibilityTimeout: visibilityTimeout,
+		inFlight:          make(map[*model.Job]string),
+		done:              make(chan struct{}),
+	}
+	// Requeue the jobs orphaned by a previous process before handing out new ones.
+	q.reap()
+	q.wg.Add(1)
+	go q.startReaper()
+	return q
+}
+
+func (q *reliableRedisQueue) keys() []string {
+	return []string{
+		q.GetRedisPrefixedKey("job-queue:processing"),
+		q.GetRedisPrefixedKey("job-queue:leases"),
+	}
+}
+
+// Pop removes and returns the head job from the queue, the job is redelivered
+// unless it gets acknowledged within the visibility timeout.
+func (q *reliableRedisQueue) Pop() *model.Job {
+	deadline := time.Now().Add(q.visibilityTimeout).UnixMilli()
+	keys := append(q.keys(), q.queueKeys()...)
+	payload, err := reliablePopScript.Run(ctx, q.Client, keys, deadline).Text()
+	if err != nil {
+		if err != redis.Nil {
+			q.logger.Errorf("could not pop job message: %s", err)
+		}
+		return nil
+	}
+	var j *model.Job
+	err = json.Unmarshal([]byte(payload), &j)
+	if err != nil {
+		q.logger.Errorf("could not unmarshal message body: %s", err)
+		// Move the poison message out of the way, it would be redelivered forever.
+		if err := q.PushDeadLetter(newDecodeDeadLetter(payload, err)); err != nil {
+			q.logger.Errorf("could not dead-letter message: %s", err)
+			return nil
+		}
+		q.remove(payload)
+		return nil
+	}
+	q.mu.Lock()
+	q.inFlight[j] = payload
+	q.mu.Unlock()
+	return j
+}
+
+// Ack acknowledges the job as popped from the queue, so it won't be redelivered.
+func (q *reliableRedisQueue) Ack(j *model.Job) error {
+	q.mu.Lock()
+	payload, ok := q.inFlight[j]
+	delete(q.inFlight, j)
+	q.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return q.remove(payload)
+}
+
+func (q *reliableRedisQueue) remove(payload string) error {
+	err := ackScript.Run(ctx, q.Client, q.keys(), payload).Err()
+	if err != nil {
+		q.logger.Errorf("could not ack job message: %s", err)
+	}
+	return err
+}
+
+func (q *reliableRedisQueue) reap() {
+	now := time.Now().UnixMilli()
+	requeued, err := reapScript.Run(ctx, q.Client, q.keys(), now, q.queueKey(model.MinPriority), model.MaxPriority).Int()
+	if err != nil {
+		q.logger.Errorf("could not requeue expired job messages: %s", err)
+		return
+	}
+	if requeued > 0 {
+		q.logger.Warnf("requeued %d job messages which were not acknowledged in time", requeued)
+	}
+}
+
+func (q *reliableRedisQueue) startReaper() {
+	defer q.wg.Done()
+	interval := q.visibilityTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-ticker.C:
+			q.reap()
+		}
+	}
+}
+
+func (q *reliableRedisQueue) Close() {
+	close(q.done)
+	q.wg.Wait()
+	q.redisQueue.Close()
+}
@@ -0,0 +1,107 @@
+package jobqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/config"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestReliableRedisQueue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := config.Redis{URL: "redis://" + mr.Addr(), KeyPrefix: "automater", PoolSize: 2, MinIdleConns: 1}
+	// A negative visibility timeout leases the popped jobs already expired.
+	q := NewReliableRedisQueue(cfg, -time.Second, "text")
+	defer q.Close()
+
+	for _, uuid := range []string{"job_1", "job_2"} {
+		if err := q.Push(&model.Job{UUID: uuid, Status: model.Pending}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j1 := q.Pop()
+	if j1 == nil || j1.UUID != "job_1" {
+		t.Fatalf("expected job_1, got %v", j1)
+	}
+	if err := q.Ack(j1); err != nil {
+		t.Fatal(err)
+	}
+	j2 := q.Pop()
+	if j2 == nil || j2.UUID != "job_2" {
+		t.Fatalf("expected job_2, got %v", j2)
+	}
+	if j := q.Pop(); j != nil {
+		t.Fatalf("expected empty queue, got %v", j)
+	}
+
+	// job_2 was never acked, so the reaper must redeliver it.
+	q.reap()
+	j := q.Pop()
+	if j == nil || j.UUID != "job_2" {
+		t.Fatalf("expected job_2 to be redelivered, got %v", j)
+	}
+	if err := q.Ack(j); err != nil {
+		t.Fatal(err)
+	}
+	q.reap()
+	if j := q.Pop(); j != nil {
+		t.Fatalf("expected acked jobs not to be redelivered, got %v", j)
+	}
+}
+
+func TestReliableRedisQueue_RedeliversToPriority(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := config.Redis{URL: "redis://" + mr.Addr(), KeyPrefix: "automater", PoolSize: 2, MinIdleConns: 1}
+	q := NewReliableRedisQueue(cfg, -time.Second, "text")
+	defer q.Close()
+
+	if err := q.Push(&model.Job{UUID: "urgent", Status: model.Pending, Priority: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if j := q.Pop(); j == nil || j.UUID != "urgent" {
+		t.Fatalf("expected urgent, got %v", j)
+	}
+	if err := q.Push(&model.Job{UUID: "bulk", Status: model.Pending}); err != nil {
+		t.Fatal(err)
+	}
+	q.reap()
+	if j := q.Pop(); j == nil || j.UUID != "urgent" {
+		t.Fatalf("expected urgent to be redelivered ahead of bulk, got %v", j)
+	}
+}
+
+func TestReliableRedisQueue_AcksEveryDelivery(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := config.Redis{URL: "redis://" + mr.Addr(), KeyPrefix: "automater", PoolSize: 2, MinIdleConns: 1}
+	q := NewReliableRedisQueue(cfg, time.Minute, "text")
+	defer q.Close()
+
+	// The same message pushed twice is delivered twice.
+	for i := 0; i < 2; i++ {
+		if err := q.Push(&model.Job{UUID: "job_1", Status: model.Pending}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := q.Pop(), q.Pop()
+	if first == nil || second == nil {
+		t.Fatalf("expected two deliveries of job_1, got %v and %v", first, second)
+	}
+	if err := q.Ack(first); err != nil {
+		t.Fatal(err)
+	}
+	keys := q.keys()
+	if processing, _ := mr.List(keys[0]); len(processing) != 1 {
+		t.Fatalf("expected the second delivery in flight, got %v", processing)
+	}
+	if leased, _ := mr.ZMembers(keys[1]); len(leased) != 1 {
+		t.Fatalf("expected the second delivery to keep its lease, got %v", leased)
+	}
+	if err := q.Ack(second); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(keys[0]) || mr.Exists(keys[1]) {
+		t.Fatal("expected both deliveries to be acked")
+	}
+}
@@ -0,0 +1,40 @@
+package automater
+
+import (
+	"time"
+
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+// MissedRuns counts the runs of a recurring schedule from runAt until now, runAt included, up to limit.
+func MissedRuns(runAt, now time.Time, runOnInterval, cronExpr, timezone string, limit int) (int, error) {
+	r, err := newRecurrence(runOnInterval, cronExpr, timezone)
+	if err != nil {
+		return 0, err
+	}
+	missed := 0
+	for t := runAt; !t.IsZero() && !t.After(now) && missed < limit; t = r.next(t) {
+		missed++
+	}
+	return missed, nil
+}
+
+// catchUpRunAt returns the next missed run to catch up with the run_all misfire policy, if any is left.
+func catchUpRunAt(catchUp *int, runAt *time.Time, runOnInterval, cronExpr, timezone string) *time.Time {
+	if *catchUp <= 1 || runAt == nil {
+		*catchUp = 0
+		return nil
+	}
+	r, err := newRecurrence(runOnInterval, cronExpr, timezone)
+	if err != nil {
+		*catchUp = 0
+		return nil
+	}
+	next := r.next(*runAt)
+	if next.IsZero() || next.After(ttime.New().Now()) {
+		*catchUp = 0
+		return nil
+	}
+	*catchUp--
+	return &next
+}
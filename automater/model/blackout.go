@@ -0,0 +1,203 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/pkg/helpers/schedule"
+)
+
+// clockLayout is the layout of the start and end of the weekly ranges, eg: 08:30.
+const clockLayout = "15:04"
+
+// maxChainedWindows bounds the overlapping windows followed to find the end of a blackout.
+const maxChainedWindows = 64
+
+// WeeklyRange is a range of the day repeating every week, an end before the start spans midnight, eg: 22:00 to 06:00.
+type WeeklyRange struct {
+	// Days are the days of the week the range starts on, eg: monday or mon, every day if empty.
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// AbsoluteRange is a one-off range, eg: a planned site shutdown.
+type AbsoluteRange struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// Blackout is a named maintenance window, the due work of the jobs it applies to is deferred to the end of the window.
+type Blackout struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Global blackouts apply to every job and pipeline, the others only to the jobs and pipelines naming them.
+	Global bool `json:"global"`
+
+	// Timezone is the IANA timezone of the weekly ranges, it defaults to the timezone of the host.
+	Timezone string `json:"timezone,omitempty"`
+
+	Weekly   []WeeklyRange   `json:"weekly,omitempty"`
+	Absolute []AbsoluteRange `json:"absolute,omitempty"`
+
+	// CreatedAt is the UTC timestamp of the blackout creation.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// NewBlackout initializes and returns a new Blackout instance.
+func NewBlackout(
+	uuid, name, description, timezone string, global bool,
+	weekly []WeeklyRange, absolute []AbsoluteRange, createdAt *time.Time) *Blackout {
+
+	return &Blackout{
+		UUID:        uuid,
+		Name:        name,
+		Description: description,
+		Global:      global,
+		Timezone:    timezone,
+		Weekly:      weekly,
+		Absolute:    absolute,
+		CreatedAt:   createdAt,
+	}
+}
+
+// Validate makes a sanity check on the blackout.
+func (b *Blackout) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("name required")
+	}
+	if len(b.Weekly) == 0 && len(b.Absolute) == 0 {
+		return fmt.Errorf("weekly or absolute ranges required")
+	}
+	if _, err := schedule.LoadLocation(b.Timezone); err != nil {
+		return fmt.Errorf("%s is not a valid timezone: %s", b.Timezone, err)
+	}
+	for _, r := range b.Weekly {
+		for _, day := range r.Days {
+			if _, ok := parseWeekday(day); !ok {
+				return fmt.Errorf("%s is not a valid day of the week", day)
+			}
+		}
+		start, err := time.Parse(clockLayout, r.Start)
+		if err != nil {
+			return fmt.Errorf("%s is not a valid start, the format is HH:MM", r.Start)
+		}
+		end, err := time.Parse(clockLayout, r.End)
+		if err != nil {
+			return fmt.Errorf("%s is not a valid end, the format is HH:MM", r.End)
+		}
+		if start.Equal(end) {
+			return fmt.Errorf("the range %s to %s is empty", r.Start, r.End)
+		}
+	}
+	for _, r := range b.Absolute {
+		if r.Start == nil || r.End == nil {
+			return fmt.Errorf("absolute ranges require a start and an end")
+		}
+		if !r.End.After(*r.Start) {
+			return fmt.Errorf("the absolute range ending at %s should end after its start", r.End.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether the blackout applies to work attached to the given blackouts, by name or uuid.
+func (b *Blackout) AppliesTo(blackouts []string) bool {
+	if b.Global {
+		return true
+	}
+	for _, name := range blackouts {
+		if name == b.Name || name == b.UUID {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveUntil reports whether the blackout is active at t and returns the end of the window, windows overlapping or
+// adjacent to each other are followed to the end of the last one.
+func (b *Blackout) ActiveUntil(t time.Time) (time.Time, bool) {
+	loc, err := schedule.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, active := b.windowEnd(t, loc)
+	if !active {
+		return time.Time{}, false
+	}
+	for i := 0; i < maxChainedWindows; i++ {
+		next, ok := b.windowEnd(end, loc)
+		if !ok || !next.After(end) {
+			break
+		}
+		end = next
+	}
+	return end, true
+}
+
+// windowEnd returns the latest end of the windows active at t.
+func (b *Blackout) windowEnd(t time.Time, loc *time.Location) (time.Time, bool) {
+	var end time.Time
+	active := false
+	for _, r := range b.Absolute {
+		if r.Start == nil || r.End == nil {
+			continue
+		}
+		if !t.Before(*r.Start) && t.Before(*r.End) && r.End.After(end) {
+			end, active = *r.End, true
+		}
+	}
+	local := t.In(loc)
+	for _, r := range b.Weekly {
+		start, err := time.Parse(clockLayout, r.Start)
+		if err != nil {
+			continue
+		}
+		stop, err := time.Parse(clockLayout, r.End)
+		if err != nil {
+			continue
+		}
+		// A range spanning midnight may have started the day before.
+		for offset := -1; offset <= 0; offset++ {
+			day := local.AddDate(0, 0, offset)
+			if !r.onDay(day.Weekday()) {
+				continue
+			}
+			from := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			to := time.Date(day.Year(), day.Month(), day.Day(), stop.Hour(), stop.Minute(), 0, 0, loc)
+			if !to.After(from) {
+				to = time.Date(day.Year(), day.Month(), day.Day()+1, stop.Hour(), stop.Minute(), 0, 0, loc)
+			}
+			if !t.Before(from) && t.Before(to) && to.After(end) {
+				end, active = to, true
+			}
+		}
+	}
+	return end, active
+}
+
+func (r WeeklyRange) onDay(weekday time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, day := range r.Days {
+		if d, ok := parseWeekday(day); ok && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(day string) (time.Weekday, bool) {
+	day = strings.ToLower(day)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if day == name || day == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,207 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The fields of a step a condition can look up, eg: steps.ping.result.ok or steps.ping.status == "FAILED".
+const (
+	StepResult = "result"
+	StepStatus = "status"
+	StepError  = "error"
+)
+
+// Upstream is a step a pipeline step waits for, and the status that step must end with for the pipeline step to run.
+type Upstream struct {
+	Step   string
+	Status JobStatus
+}
+
+// HasDependencies checks if the job waits for other steps of its pipeline.
+func (j *Job) HasDependencies() bool {
+	return len(j.DependsOn) > 0
+}
+
+// IsConditional checks if the job declares a condition or branches.
+func (j *Job) IsConditional() bool {
+	return j.When != "" || len(j.OnSuccess) > 0 || len(j.OnFailure) > 0
+}
+
+// HandlesFailure checks if the job branches to other steps on failure, its failure doesn't fail the pipeline then.
+func (j *Job) HandlesFailure() bool {
+	return len(j.OnFailure) > 0
+}
+
+// IsDAG checks if the steps of the pipeline run as a graph, that is as soon as the steps they wait for ended, rather
+// than one after the other.
+func (p *Pipeline) IsDAG() bool {
+	return IsDAG(p.Jobs)
+}
+
+// IsDAG checks if any of the steps declares depends_on, a condition or branches.
+func IsDAG(jobs []*Job) bool {
+	for _, j := range jobs {
+		if j.HasDependencies() || j.IsConditional() {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresDependencies checks if any of the steps declares depends_on.
+func declaresDependencies(jobs []*Job) bool {
+	for _, j := range jobs {
+		if j.HasDependencies() {
+			return true
+		}
+	}
+	return false
+}
+
+// Upstreams returns the steps each step waits for by step name: the ones it depends on, which must complete, and the
+// ones branching to it on success or on failure.
+func Upstreams(jobs []*Job) map[string][]Upstream {
+	upstreams := make(map[string][]Upstream, len(jobs))
+	for _, j := range jobs {
+		for _, name := range j.DependsOn {
+			upstreams[j.Name] = append(upstreams[j.Name], Upstream{Step: name, Status: Completed})
+		}
+	}
+	for _, j := range jobs {
+		for _, name := range j.OnSuccess {
+			upstreams[name] = append(upstreams[name], Upstream{Step: j.Name, Status: Completed})
+		}
+		for _, name := range j.OnFailure {
+			upstreams[name] = append(upstreams[name], Upstream{Step: j.Name, Status: Failed})
+		}
+	}
+	return upstreams
+}
+
+// SortSteps validates the graph of the steps and returns them in topological order, the steps keep the order they were
+// given in as long as their dependencies allow. The steps are identified by their names, which must be unique, must
+// wait for existing steps without cycles, and their conditions and templates can only look up the steps they wait for,
+// directly or not. A graph made by conditions and branches alone must start from a single step, the steps listed one
+// after the other would run in parallel otherwise.
+func SortSteps(jobs []*Job) ([]*Job, error) {
+	steps := make(map[string]*Job, len(jobs))
+	for _, j := range jobs {
+		if j.Name == "" {
+			return nil, fmt.Errorf("pipeline steps should have a name")
+		}
+		if _, ok := steps[j.Name]; ok {
+			return nil, fmt.Errorf("pipeline step %s is defined more than once", j.Name)
+		}
+		steps[j.Name] = j
+	}
+	for _, j := range jobs {
+		references := []struct {
+			verb  string
+			names []string
+		}{
+			{"depends on", j.DependsOn},
+			{"branches on success to", j.OnSuccess},
+			{"branches on failure to", j.OnFailure},
+		}
+		for _, r := range references {
+			for _, name := range r.names {
+				if _, ok := steps[name]; !ok {
+					return nil, fmt.Errorf("pipeline step %s %s unknown step %s", j.Name, r.verb, name)
+				}
+				if name == j.Name {
+					return nil, fmt.Errorf("pipeline step %s %s itself", j.Name, r.verb)
+				}
+			}
+		}
+	}
+	upstreams := Upstreams(jobs)
+	if !declaresDependencies(jobs) {
+		var roots []string
+		for _, j := range jobs {
+			if len(upstreams[j.Name]) == 0 {
+				roots = append(roots, j.Name)
+			}
+		}
+		if len(roots) > 1 {
+			return nil, fmt.Errorf("pipeline steps %s wait for no other step and would run in parallel, declare their depends_on to order them",
+				strings.Join(roots, ", "))
+		}
+	}
+	waiting := make(map[string]int, len(jobs))
+	for _, j := range jobs {
+		seen := make(map[string]bool, len(upstreams[j.Name]))
+		for _, u := range upstreams[j.Name] {
+			if seen[u.Step] {
+				return nil, fmt.Errorf("pipeline step %s waits for %s more than once", j.Name, u.Step)
+			}
+			seen[u.Step] = true
+		}
+		waiting[j.Name] = len(upstreams[j.Name])
+	}
+
+	sorted := make([]*Job, 0, len(jobs))
+	ancestors := make(map[string]map[string]bool, len(jobs))
+	for len(sorted) < len(jobs) {
+		progress := false
+		for _, j := range jobs {
+			if ancestors[j.Name] != nil || waiting[j.Name] > 0 {
+				continue
+			}
+			ancestors[j.Name] = map[string]bool{}
+			for _, u := range upstreams[j.Name] {
+				ancestors[j.Name][u.Step] = true
+				for name := range ancestors[u.Step] {
+					ancestors[j.Name][name] = true
+				}
+			}
+			sorted = append(sorted, j)
+			progress = true
+			for _, dependent := range jobs {
+				for _, u := range upstreams[dependent.Name] {
+					if u.Step == j.Name {
+						waiting[dependent.Name]--
+					}
+				}
+			}
+			// Start over so the earlier steps go first.
+			break
+		}
+		if !progress {
+			var cycle []string
+			for _, j := range jobs {
+				if ancestors[j.Name] == nil {
+					cycle = append(cycle, j.Name)
+				}
+			}
+			return nil, fmt.Errorf("pipeline steps %s can not run, their dependencies form a cycle", strings.Join(cycle, ", "))
+		}
+	}
+
+	for _, j := range sorted {
+		if err := j.validateLookups(ancestors[j.Name]); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Ready tells whether a waiting job can run, given the steps it waits for and the steps of its pipeline by name. The
+// job is ready once all the steps it waits for ended with the expected status, and blocked for good if one of them
+// ended with another status.
+func (j *Job) Ready(upstreams []Upstream, steps map[string]*Job) (ready bool, blocked bool) {
+	ready = true
+	for _, u := range upstreams {
+		step, ok := steps[u.Step]
+		switch {
+		case !ok:
+			return false, true
+		case step.Status == u.Status:
+		case step.IsActive():
+			ready = false
+		default:
+			return false, true
+		}
+	}
+	return ready, false
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// DeadLetter is a job message that can't be processed any further, kept for inspection and requeueing.
+type DeadLetter struct {
+	UUID string `json:"uuid"`
+
+	// Job is the dead-lettered job, nil if the message could not be decoded.
+	Job *Job `json:"job,omitempty"`
+
+	// Payload is the raw message of a job that could not be decoded.
+	Payload string `json:"payload,omitempty"`
+
+	// Reason describes why the job was dead-lettered.
+	Reason string `json:"reason"`
+
+	// CreatedAt is the UTC timestamp of the moment the job was dead-lettered.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// NewDeadLetter initializes and returns a new DeadLetter instance.
+func NewDeadLetter(uuid string, j *Job, payload, reason string, createdAt *time.Time) *DeadLetter {
+	return &DeadLetter{
+		UUID:      uuid,
+		Job:       j,
+		Payload:   payload,
+		Reason:    reason,
+		CreatedAt: createdAt,
+	}
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/schedule"
 	"strings"
 	"time"
 )
@@ -15,20 +16,69 @@ if the job is to be marked as completed and the job is set to be a CRON job then
 - bump the Job.RunCount up one
 - reset the Job.RunAt time to completed plus the next interval eg: completed at 3pm and interval is 15min then set RunAt=03:15
 
-Rest on fail
-- if the job has failed and the OnFailRetry is true
-- bump the Job.FailCount up one
-- reset the Job.RunAt time to completed plus the next interval eg: completed at 3pm and interval is 15min then set RunAt=03:15
+Retry on fail
+- every attempt bumps the Job.Attempt up one and stores a Transaction
+- if the job has failed bump the Job.FailCount up one
+- if the EnableOnFailRetry is true and the job has attempts left, set it back to PENDING with RunAt=failed at plus the retry delay,
+  the steps of a pipeline are retried in place instead so the next steps keep waiting
+- the RunAt the retried run was due at is kept as the Job.ScheduleAnchor, the schedule of a recurring job carries on from it
+- the job is FAILED once it ran out of attempts
 
 */
 
+const (
+	// MinPriority is the default priority of jobs and pipelines.
+	MinPriority = 0
+	// MaxPriority is the most urgent priority, such work jumps ahead of everything else.
+	MaxPriority = 9
+)
+
+// DefaultQueue is the queue of the jobs without a queue, served by the worker_pool workers.
+const DefaultQueue = "default"
+
+const (
+	// ConcurrencyAllow lets the runs of a job overlap, it's the default.
+	ConcurrencyAllow = "allow"
+	// ConcurrencyForbid skips a run while the previous run of the job is still in progress.
+	ConcurrencyForbid = "forbid"
+	// ConcurrencySkip is an alias of ConcurrencyForbid.
+	ConcurrencySkip = "skip"
+	// ConcurrencyReplace stops the run in progress and starts the new one.
+	ConcurrencyReplace = "replace"
+)
+
 type JobOptions struct {
-	EnableInterval    bool       `json:"enable_interval"`
-	RunOnInterval     string     `json:"run_on_interval"`
-	EnableOnFailRetry bool       `json:"enable_on_fail_retry"`
-	HowTimesToRetry   bool       `json:"how_times_to_retry"`
-	OnFailRetryDelay  *time.Time `json:"birth,omitempty"`
-	OrderOfExecution  int        `json:"order_of_execution"`
+	EnableInterval bool   `json:"enable_interval"`
+	RunOnInterval  string `json:"run_on_interval"`
+	// Cron is a cron expression (5 or 6 fields, or a descriptor like @hourly), it takes precedence over RunOnInterval.
+	Cron string `json:"cron,omitempty"`
+	// Timezone is the IANA timezone of the schedule, eg: Australia/Sydney, it defaults to the timezone of the host.
+	Timezone          string `json:"timezone,omitempty"`
+	EnableOnFailRetry bool   `json:"enable_on_fail_retry"`
+	// Deprecated: HowTimesToRetry is ignored, use MaxAttempts. It's kept so the stored jobs keep their fields.
+	HowTimesToRetry bool `json:"how_times_to_retry"`
+	// Deprecated: OnFailRetryDelay is ignored, use RetryDelay. It's kept so the stored jobs keep their fields.
+	OnFailRetryDelay *time.Time `json:"birth,omitempty"`
+	// MaxAttempts is the number of attempts including the first one, it defaults to DefaultMaxAttempts.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// RetryBackoff is either RetryFixed (the default) or RetryExponential.
+	RetryBackoff string `json:"retry_backoff,omitempty"`
+	// RetryDelay is the delay before the first retry, it defaults to DefaultRetryDelay.
+	RetryDelay    int `json:"retry_delay_in_sec,omitempty"`
+	RetryMaxDelay int `json:"retry_max_delay_in_sec,omitempty"`
+	// RetryJitter takes up to this fraction (0 to 1) off every delay at random.
+	RetryJitter      float64 `json:"retry_jitter,omitempty"`
+	OrderOfExecution int     `json:"order_of_execution"`
+	// ConcurrencyPolicy is one of ConcurrencyAllow, ConcurrencyForbid (or ConcurrencySkip) and ConcurrencyReplace,
+	// the jobs of a pipeline take the pipeline policy.
+	ConcurrencyPolicy string `json:"concurrency_policy,omitempty"`
+	// MisfirePolicy is one of MisfireRunOnce (the default), MisfireRunAll and MisfireSkip.
+	MisfirePolicy string `json:"misfire_policy,omitempty"`
+	// MisfireLimit is the most missed runs caught up with MisfireRunAll, it defaults to DefaultMisfireLimit.
+	MisfireLimit int `json:"misfire_limit,omitempty"`
+	// Blackouts are the names (or uuids) of the blackout windows deferring the runs of the job, on top of the global
+	// ones, the jobs of a pipeline take the pipeline blackouts too.
+	Blackouts []string `json:"blackouts,omitempty"`
 }
 
 // Job represents an async tasks.
@@ -37,9 +87,19 @@ type Job struct {
 	Name    string `json:"name"`
 	Disable bool   `json:"disable"`
 
+	// Priority orders the due work from MinPriority to MaxPriority (most urgent), pipeline jobs inherit it from the pipeline.
+	Priority int `json:"priority"`
+
+	// Queue is the name of the worker pool running the job, pipeline jobs inherit it from the pipeline.
+	Queue string `json:"queue,omitempty"`
+
 	//stats on run and error count
 	RunCount  int `json:"run_count"`
 	FailCount int `json:"fail_count"`
+	// Attempt is the number of the current attempt of the run, starting from 1.
+	Attempt int `json:"attempt"`
+	// CatchUp is the number of missed runs left to run, the current one included, with the MisfireRunAll policy.
+	CatchUp int `json:"catch_up,omitempty"`
 
 	JobOptions *JobOptions `json:"job_options"`
 
@@ -55,6 +115,16 @@ type Job struct {
 	// Next points to the next job of the pipeline, if any.
 	Next *Job `json:"next,omitempty"`
 
+	// DependsOn are the names of the pipeline steps that must complete before the job runs, see Pipeline.IsDAG.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// When is a condition on the upstream steps, eg: steps.ping.result.ok == false, the job is skipped unless it holds.
+	When string `json:"when,omitempty"`
+
+	// OnSuccess and OnFailure are the names of the pipeline steps to run once the job completed, or failed for good.
+	OnSuccess []string `json:"on_success,omitempty"`
+	OnFailure []string `json:"on_failure,omitempty"`
+
 	// TaskName is the name of the tasks to be executed.
 	TaskName string `json:"task_name"`
 
@@ -74,6 +144,9 @@ type Job struct {
 	FailureReason string `json:"failure_reason,omitempty"`
 	// RunAt is the UTC timestamp indicating the time for the job to run.
 	RunAt *time.Time `json:"run_at,omitempty"`
+	// ScheduleAnchor is the UTC timestamp the run was due at on the schedule, kept while the run_at is postponed, eg: by
+	// a retry. The schedule of a recurring job carries on from it.
+	ScheduleAnchor *time.Time `json:"schedule_anchor,omitempty"`
 	// RunAt is like run every 15min
 	// ScheduledAt is the UTC timestamp indicating the time that the job got scheduled.
 	ScheduledAt *time.Time `json:"scheduled_at"`
@@ -85,6 +158,9 @@ type Job struct {
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	// Duration indicates how much the job took to complete.
 	Duration *time.Duration `json:"duration,omitempty"`
+
+	// noRetry gives up the attempts left of the current run, see MarkFailedForGood.
+	noRetry bool
 }
 
 // NewJob initializes and returns a new Job instance.
@@ -119,10 +195,16 @@ func (j *Job) MarkPending() {
 	j.Status = Pending
 }
 
-// MarkStarted updates the status and timestamp at the moment the job started.
+// MarkStarted updates the status and timestamp at the moment the job started, and counts the attempt. The first
+// attempt counts the run.
 func (j *Job) MarkStarted(startedAt *time.Time) {
 	j.Status = InProgress
 	j.StartedAt = startedAt
+	j.noRetry = false
+	j.Attempt++
+	if j.Attempt == 1 {
+		j.RunCount++
+	}
 }
 
 // MarkScheduled updates the status and timestamp at the moment the job got scheduled.
@@ -147,21 +229,83 @@ func (j *Job) MarkFailed(failedAt *time.Time, reason string) {
 	j.Status = Failed
 	j.FailureReason = reason
 	j.CompletedAt = failedAt
+	j.FailCount++
+}
+
+// MarkSkipped updates the status and reason of a run that didn't happen.
+func (j *Job) MarkSkipped(skippedAt *time.Time, reason string) {
+	j.Status = Skipped
+	j.FailureReason = reason
+	j.StartedAt = skippedAt
+	j.CompletedAt = skippedAt
+}
+
+// MarkMisfired updates the status and reason of the runs missed by a recurring job.
+func (j *Job) MarkMisfired(misfiredAt *time.Time, reason string) {
+	j.Status = Misfired
+	j.FailureReason = reason
+	j.StartedAt = misfiredAt
+	j.CompletedAt = misfiredAt
+}
+
+// MarkDeferred updates the status and reason of a run deferred by a blackout window.
+func (j *Job) MarkDeferred(deferredAt *time.Time, reason string) {
+	j.Status = Deferred
+	j.FailureReason = reason
+	j.StartedAt = deferredAt
+	j.CompletedAt = deferredAt
+}
+
+// MarkCancelled updates the status and reason of a job cancelled through the API.
+func (j *Job) MarkCancelled(cancelledAt *time.Time, reason string) {
+	j.Status = Cancelled
+	j.FailureReason = reason
+	j.CompletedAt = cancelledAt
+}
+
+// IsActive reports whether the job is waiting to run or running.
+func (j *Job) IsActive() bool {
+	return j.Status == Pending || j.Status == Scheduled || j.Status == InProgress
+}
+
+// MarkRetry sets a failed job back to pending, to run again at the given time.
+func (j *Job) MarkRetry(runAt *time.Time) {
+	j.Status = Pending
+	j.Postpone(runAt)
+}
+
+// Postpone moves the run_at of the job, the run_at it was due at on the schedule is kept as the schedule anchor.
+func (j *Job) Postpone(runAt *time.Time) {
+	if j.ScheduleAnchor == nil {
+		j.ScheduleAnchor = j.RunAt
+	}
+	j.RunAt = runAt
 }
 
 // SetDuration sets the duration of the job if it's completed of failed.
 func (j *Job) SetDuration() {
-	if j.Status == Completed || j.Status == Failed {
+	if (j.Status == Completed || j.Status == Failed) && j.StartedAt != nil && j.CompletedAt != nil {
 		duration := j.CompletedAt.Sub(*j.StartedAt) / time.Millisecond
 		j.Duration = &duration
 	}
 }
 
+// Localize converts the run_at of the job in place to the timezone of the job, if it has one.
+func (j *Job) Localize() {
+	if j.RunAt != nil && j.JobOptions != nil && j.JobOptions.Timezone != "" {
+		runAt := schedule.In(*j.RunAt, j.JobOptions.Timezone)
+		j.RunAt = &runAt
+	}
+}
+
 // IsRecycleJob check if user wants to reuse the job
 func (j *Job) IsRecycleJob() bool {
 	if j.PipelineID != "" {
 		return false
 	}
+	if j.JobOptions != nil && j.JobOptions.Cron != "" {
+		return true
+	}
 	if j.JobOptions != nil && j.JobOptions.EnableInterval {
 		if j.JobOptions.RunOnInterval == "" {
 			j.JobOptions.RunOnInterval = "1 sec"
@@ -192,6 +336,20 @@ func (j *Job) Validate(taskRepo *taskRepo.TaskRepository) error {
 		return fmt.Errorf(strings.Join(required, ", ") + " required")
 	}
 
+	if err := ValidatePriority(j.Priority); err != nil {
+		return err
+	}
+
+	if j.JobOptions != nil {
+		if err := j.JobOptions.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := j.validateTemplates(); err != nil {
+		return err
+	}
+
 	_, err := taskRepo.GetTaskFunc(j.TaskName)
 	if err != nil {
 		taskNames := taskRepo.GetTaskNames()
@@ -207,6 +365,71 @@ func (j *Job) Validate(taskRepo *taskRepo.TaskRepository) error {
 	return nil
 }
 
+// Validate checks the schedule, retry, concurrency and misfire options.
+func (o *JobOptions) Validate() error {
+	if o.Cron != "" {
+		if err := schedule.Validate(o.Cron); err != nil {
+			return fmt.Errorf("%s is not a valid cron expression: %s", o.Cron, err)
+		}
+	}
+	if err := o.validateRetry(); err != nil {
+		return err
+	}
+	if err := ValidateConcurrencyPolicy(o.ConcurrencyPolicy); err != nil {
+		return err
+	}
+	if err := ValidateMisfirePolicy(o.MisfirePolicy, o.MisfireLimit); err != nil {
+		return err
+	}
+	if _, err := schedule.LoadLocation(o.Timezone); err != nil {
+		return fmt.Errorf("%s is not a valid timezone: %s", o.Timezone, err)
+	}
+	return nil
+}
+
+// ClampPriority returns the priority clamped to MinPriority and MaxPriority.
+func ClampPriority(priority int) int {
+	if priority < MinPriority {
+		return MinPriority
+	}
+	if priority > MaxPriority {
+		return MaxPriority
+	}
+	return priority
+}
+
+// ValidatePriority checks that the priority is within MinPriority and MaxPriority.
+func ValidatePriority(priority int) error {
+	if priority < MinPriority || priority > MaxPriority {
+		return fmt.Errorf("priority should be between %d and %d, %d given", MinPriority, MaxPriority, priority)
+	}
+	return nil
+}
+
+// ValidateConcurrencyPolicy checks that the policy is a known concurrency policy.
+func ValidateConcurrencyPolicy(policy string) error {
+	switch policy {
+	case "", ConcurrencyAllow, ConcurrencyForbid, ConcurrencySkip, ConcurrencyReplace:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid concurrency policy - valid policies: %s, %s, %s, %s",
+		policy, ConcurrencyAllow, ConcurrencyForbid, ConcurrencySkip, ConcurrencyReplace)
+}
+
+// ConcurrencyPolicy returns the concurrency policy of the job, ConcurrencySkip is returned as ConcurrencyForbid.
+func (j *Job) ConcurrencyPolicy() string {
+	if j.JobOptions == nil {
+		return ConcurrencyAllow
+	}
+	switch j.JobOptions.ConcurrencyPolicy {
+	case ConcurrencyForbid, ConcurrencySkip:
+		return ConcurrencyForbid
+	case ConcurrencyReplace:
+		return ConcurrencyReplace
+	}
+	return ConcurrencyAllow
+}
+
 func (j *Job) IsScheduled() bool {
 	return j.RunAt != nil
 }
@@ -6,7 +6,7 @@ import (
 	"strconv"
 )
 
-// JobStatus holds a value for job status ranging from 1 to 5.
+// JobStatus holds a value for job status ranging from 1 to 9.
 type JobStatus int
 
 const (
@@ -16,6 +16,10 @@ const (
 	InProgress                  // 3
 	Completed                   // 4
 	Failed                      // 5
+	Skipped                     // 6
+	Misfired                    // 7
+	Deferred                    // 8
+	Cancelled                   // 9
 
 	UNDERFINED = "UNDERFINED"
 	PENDING    = "PENDING"
@@ -23,12 +27,16 @@ const (
 	INPROGRESS = "IN_PROGRESS"
 	COMPLETED  = "COMPLETED"
 	FAILED     = "FAILED"
+	SKIPPED    = "SKIPPED"
+	MISFIRED   = "MISFIRED"
+	DEFERRED   = "DEFERRED"
+	CANCELLED  = "CANCELLED"
 )
 
 // String converts the type to a string.
 func (js JobStatus) String() string {
 	if js != 0 {
-		return [...]string{PENDING, SCHEDULED, INPROGRESS, COMPLETED, FAILED}[js-1]
+		return [...]string{PENDING, SCHEDULED, INPROGRESS, COMPLETED, FAILED, SKIPPED, MISFIRED, DEFERRED, CANCELLED}[js-1]
 	}
 	return UNDERFINED
 
@@ -53,6 +61,10 @@ func (js *JobStatus) UnmarshalJSON(data []byte) error {
 		INPROGRESS: InProgress,
 		COMPLETED:  Completed,
 		FAILED:     Failed,
+		SKIPPED:    Skipped,
+		MISFIRED:   Misfired,
+		DEFERRED:   Deferred,
+		CANCELLED:  Cancelled,
 	}
 
 	unquotedJobStatus, err := strconv.Unquote(string(data))
@@ -78,6 +90,10 @@ func (js JobStatus) Validate() error {
 		InProgress: InProgress.Index(),
 		Completed:  Completed.Index(),
 		Failed:     Failed.Index(),
+		Skipped:    Skipped.Index(),
+		Misfired:   Misfired.Index(),
+		Deferred:   Deferred.Index(),
+		Cancelled:  Cancelled.Index(),
 	}
 	if _, ok := validJobStatuses[js]; !ok {
 		err = fmt.Errorf("%d is not a valid job status, valid statuses: %v", js, validJobStatuses)
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Lease is the claim of an automater instance on a job it holds, waiting in a worker pool backlog or running. The
+// instance renews its leases with heartbeats, the job of an expired lease is orphaned, eg: the process died mid-run.
+type Lease struct {
+	JobID string `json:"job_id"`
+	// Owner identifies the automater instance holding the job.
+	Owner string `json:"owner"`
+	// ExpiresAt is the UTC timestamp the lease lasts until if it's not renewed.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// NewLease initializes and returns a new Lease instance.
+func NewLease(jobID, owner string, expiresAt *time.Time) *Lease {
+	return &Lease{
+		JobID:     jobID,
+		Owner:     owner,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// IsExpired reports whether the lease expired at t.
+func (l *Lease) IsExpired(t time.Time) bool {
+	return l.ExpiresAt == nil || !t.Before(*l.ExpiresAt)
+}
+
+// Leadership is the claim of an automater instance on the scheduler, only the leader schedules the due jobs.
+type Leadership struct {
+	// Owner identifies the leading automater instance.
+	Owner string `json:"owner"`
+	// Token is the fencing token of the leadership, it's bumped every time the leadership changes hands so a stale
+	// leader can't claim jobs anymore.
+	Token int64 `json:"token"`
+	// ExpiresAt is the UTC timestamp the leadership lasts until if it's not renewed.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IsExpired reports whether the leadership expired at t.
+func (l *Leadership) IsExpired(t time.Time) bool {
+	return l.ExpiresAt == nil || !t.Before(*l.ExpiresAt)
+}
@@ -0,0 +1,55 @@
+package model
+
+import "fmt"
+
+const (
+	// MisfireRunOnce runs a recurring job once for all the runs it missed, it's the default.
+	MisfireRunOnce = "run_once"
+	// MisfireRunAll runs every missed run, up to the misfire limit, before moving on to the next run.
+	MisfireRunAll = "run_all"
+	// MisfireSkip skips the missed runs and waits for the next run.
+	MisfireSkip = "skip"
+
+	// DefaultMisfireLimit is the most missed runs caught up with MisfireRunAll when no misfire_limit is set.
+	DefaultMisfireLimit = 10
+)
+
+// ValidateMisfirePolicy checks the misfire policy and its limit.
+func ValidateMisfirePolicy(policy string, limit int) error {
+	switch policy {
+	case "", MisfireRunOnce, MisfireRunAll, MisfireSkip:
+	default:
+		return fmt.Errorf("%s is not a valid misfire policy - valid policies: %s, %s, %s",
+			policy, MisfireRunOnce, MisfireRunAll, MisfireSkip)
+	}
+	if limit < 0 {
+		return fmt.Errorf("misfire_limit should be positive, %d given", limit)
+	}
+	return nil
+}
+
+// MisfirePolicyAndLimit returns the misfire policy of the job and the most runs to catch up.
+func (o *JobOptions) MisfirePolicyAndLimit() (string, int) {
+	if o == nil {
+		return misfirePolicy("", 0)
+	}
+	return misfirePolicy(o.MisfirePolicy, o.MisfireLimit)
+}
+
+// MisfirePolicyAndLimit returns the misfire policy of the pipeline and the most runs to catch up.
+func (o *PipelineOptions) MisfirePolicyAndLimit() (string, int) {
+	if o == nil {
+		return misfirePolicy("", 0)
+	}
+	return misfirePolicy(o.MisfirePolicy, o.MisfireLimit)
+}
+
+func misfirePolicy(policy string, limit int) (string, int) {
+	if policy == "" {
+		policy = MisfireRunOnce
+	}
+	if limit <= 0 {
+		limit = DefaultMisfireLimit
+	}
+	return policy, limit
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NubeIO/rubix-automater/pkg/helpers/expr"
+)
+
+// The scopes the task params templates and the step conditions look up, eg: {{ steps.lookup.result.host_uuid }},
+// {{ vars.version }} or {{ run.started_at }}.
+const (
+	ScopeSteps = "steps"
+	ScopeVars  = "vars"
+	ScopeRun   = "run"
+)
+
+// The fields of the run scope.
+const (
+	RunStartedAt  = "started_at"
+	RunJobID      = "job_id"
+	RunPipelineID = "pipeline_id"
+	RunAttempt    = "attempt"
+)
+
+// validateTemplates checks the templates of the task params, the jobs outside of a pipeline only look up the run.
+func (j *Job) validateTemplates() error {
+	paths, err := expr.Templates(j.TaskParams)
+	if err != nil {
+		return fmt.Errorf("task_params: %s", err)
+	}
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		switch {
+		case keys[0] == ScopeRun:
+			if len(keys) != 2 || (keys[1] != RunStartedAt && keys[1] != RunJobID && keys[1] != RunPipelineID && keys[1] != RunAttempt) {
+				return fmt.Errorf("task_params: %s is not a valid run field - valid fields: %s.%s, %s.%s, %s.%s, %s.%s", path,
+					ScopeRun, RunStartedAt, ScopeRun, RunJobID, ScopeRun, RunPipelineID, ScopeRun, RunAttempt)
+			}
+		case (keys[0] == ScopeSteps || keys[0] == ScopeVars) && j.BelongsToPipeline():
+			// The steps are checked along with the pipeline.
+		default:
+			return fmt.Errorf("task_params: %s is not a valid template - valid scopes: %s, and %s and %s in a pipeline",
+				path, ScopeRun, ScopeSteps, ScopeVars)
+		}
+	}
+	return nil
+}
+
+// validateLookups checks that the step conditions and the task params templates only look up the steps the job
+// waits for, given their names.
+func (j *Job) validateLookups(upstream map[string]bool) error {
+	if j.When != "" {
+		condition, err := expr.Parse(j.When)
+		if err != nil {
+			return fmt.Errorf("pipeline step %s has an invalid condition: %s", j.Name, err)
+		}
+		for _, path := range condition.Paths() {
+			if strings.HasPrefix(path, ScopeVars+".") {
+				continue
+			}
+			if err := validateStepLookup(path, upstream); err != nil {
+				return fmt.Errorf("pipeline step %s condition %s", j.Name, err)
+			}
+		}
+	}
+	paths, err := expr.Templates(j.TaskParams)
+	if err != nil {
+		return fmt.Errorf("pipeline step %s task_params: %s", j.Name, err)
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, ScopeSteps+".") {
+			continue
+		}
+		if err := validateStepLookup(path, upstream); err != nil {
+			return fmt.Errorf("pipeline step %s task_params %s", j.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateStepLookup(path string, upstream map[string]bool) error {
+	keys := strings.SplitN(path, ".", 4)
+	if len(keys) < 3 || keys[0] != ScopeSteps || (keys[2] != StepResult && keys[2] != StepStatus && keys[2] != StepError) {
+		return fmt.Errorf("looks up %s, valid lookups: %s.<name>.%s, %s.<name>.%s, %s.<name>.%s and %s.<name>",
+			path, ScopeSteps, StepResult, ScopeSteps, StepStatus, ScopeSteps, StepError, ScopeVars)
+	}
+	if !upstream[keys[1]] {
+		return fmt.Errorf("looks up step %s, which doesn't run before it", keys[1])
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Pause is the paused state of the scheduler. The paused work stays due and the work in progress keeps running, it's
+// scheduled and dispatched again once resumed.
+type Pause struct {
+	// Global pauses the scheduling and the dispatching of every queue.
+	Global bool `json:"global"`
+	// Queues are the names of the paused queues, the jobs without a queue are on the default queue.
+	Queues []string `json:"queues"`
+	// UpdatedAt is the UTC timestamp of the last pause or resume.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// IsPaused reports whether the work of the queue is paused, globally or on its own.
+func (p *Pause) IsPaused(queue string) bool {
+	return p.Global || p.IsQueuePaused(queue)
+}
+
+// IsQueuePaused reports whether the queue is paused on its own.
+func (p *Pause) IsQueuePaused(queue string) bool {
+	queue = queueName(queue)
+	for _, q := range p.Queues {
+		if q == queue {
+			return true
+		}
+	}
+	return false
+}
+
+// PauseQueue pauses the queue, or every queue if the queue is empty.
+func (p *Pause) PauseQueue(queue string, pausedAt *time.Time) {
+	p.UpdatedAt = pausedAt
+	if queue == "" {
+		p.Global = true
+		return
+	}
+	queue = queueName(queue)
+	for _, q := range p.Queues {
+		if q == queue {
+			return
+		}
+	}
+	p.Queues = append(p.Queues, queue)
+	sort.Strings(p.Queues)
+}
+
+// ResumeQueue resumes the queue, or lifts the global pause if the queue is empty. The queues paused on their own stay
+// paused when the global pause is lifted.
+func (p *Pause) ResumeQueue(queue string, resumedAt *time.Time) {
+	p.UpdatedAt = resumedAt
+	if queue == "" {
+		p.Global = false
+		return
+	}
+	queue = queueName(queue)
+	queues := make([]string, 0, len(p.Queues))
+	for _, q := range p.Queues {
+		if q != queue {
+			queues = append(queues, q)
+		}
+	}
+	p.Queues = queues
+}
+
+func queueName(queue string) string {
+	if queue == "" {
+		return DefaultQueue
+	}
+	return queue
+}
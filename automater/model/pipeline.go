@@ -2,16 +2,28 @@ package model
 
 import (
 	"fmt"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/schedule"
 	"strings"
 	"time"
 )
 
 type PipelineOptions struct {
-	Disable          bool   `json:"disable"`
-	EnableInterval   bool   `json:"enable_interval"`
-	RunOnInterval    string `json:"run_on_interval"`
+	Disable        bool   `json:"disable"`
+	EnableInterval bool   `json:"enable_interval"`
+	RunOnInterval  string `json:"run_on_interval"`
+	// Cron is a cron expression (5 or 6 fields, or a descriptor like @hourly), it takes precedence over RunOnInterval.
+	Cron string `json:"cron,omitempty"`
+	// Timezone is the IANA timezone of the schedule, eg: Australia/Sydney, it defaults to the timezone of the host.
+	Timezone         string `json:"timezone,omitempty"`
 	DelayBetweenTask int    `json:"delay_between_task_in_sec"`
 	CancelOnFailure  bool   `json:"cancel_on_failure"`
+	// ConcurrencyPolicy is the concurrency policy of the pipeline jobs, see JobOptions.
+	ConcurrencyPolicy string `json:"concurrency_policy,omitempty"`
+	// MisfirePolicy and MisfireLimit are the misfire policy of the pipeline, see JobOptions.
+	MisfirePolicy string `json:"misfire_policy,omitempty"`
+	MisfireLimit  int    `json:"misfire_limit,omitempty"`
+	// Blackouts are the blackout windows of the pipeline, see JobOptions.
+	Blackouts []string `json:"blackouts,omitempty"`
 }
 
 // Pipeline represents a sequence of async tasks.
@@ -20,17 +32,40 @@ type Pipeline struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 
+	// Priority orders the due work from MinPriority to MaxPriority (most urgent).
+	Priority int `json:"priority"`
+
+	// Queue is the name of the worker pool running the pipeline jobs.
+	Queue string `json:"queue,omitempty"`
+
 	PipelineOptions *PipelineOptions `json:"options"`
 
 	Jobs []*Job `json:"jobs,omitempty"`
 
+	// Vars are the pipeline variables the task params templates look up, eg: {{ vars.version }}.
+	Vars map[string]interface{} `json:"vars,omitempty"`
+
+	// RunVars are the variables given to the trigger of the current run, they override the pipeline variables for
+	// this run only. The next recycle drops them.
+	RunVars map[string]interface{} `json:"run_vars,omitempty"`
+
+	// TriggerVars are the variables given to a trigger, the recycle makes them the run_vars of the run it triggers.
+	TriggerVars map[string]interface{} `json:"-"`
+
 	Status JobStatus `json:"status"`
 
 	// RunAt is the UTC timestamp indicating the time for the pipeline to run.
 	RunAt *time.Time `json:"schedule_at,omitempty"`
 
+	// ScheduleAnchor is the UTC timestamp the run was due at on the schedule, kept while the run_at is postponed, eg: by
+	// a blackout. The schedule of a recurring pipeline carries on from it.
+	ScheduleAnchor *time.Time `json:"schedule_anchor,omitempty"`
+
 	RunAtUUID string `json:"run_at_uuid"`
 
+	// CatchUp is the number of missed runs left to run, the current one included, with the MisfireRunAll policy.
+	CatchUp int `json:"catch_up,omitempty"`
+
 	// CreatedAt is the UTC timestamp of the pipeline creation.
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 
@@ -75,6 +110,35 @@ func (p *Pipeline) MarkFailed(failedAt *time.Time) {
 	p.CompletedAt = failedAt
 }
 
+// MarkCancelled updates the status and timestamp at the moment the pipeline got cancelled.
+func (p *Pipeline) MarkCancelled(cancelledAt *time.Time) {
+	p.Status = Cancelled
+	p.CompletedAt = cancelledAt
+}
+
+// Postpone moves the run_at of the pipeline, the run_at it was due at on the schedule is kept as the anchor.
+func (p *Pipeline) Postpone(runAt *time.Time) {
+	if p.ScheduleAnchor == nil {
+		p.ScheduleAnchor = p.RunAt
+	}
+	p.RunAt = runAt
+}
+
+// Variables returns the variables of the current run, the run_vars override the pipeline variables.
+func (p *Pipeline) Variables() map[string]interface{} {
+	if len(p.RunVars) == 0 {
+		return p.Vars
+	}
+	vars := make(map[string]interface{}, len(p.Vars)+len(p.RunVars))
+	for k, v := range p.Vars {
+		vars[k] = v
+	}
+	for k, v := range p.RunVars {
+		vars[k] = v
+	}
+	return vars
+}
+
 // SetDuration sets the duration of the pipeline if it's completed of failed.
 func (p *Pipeline) SetDuration() {
 	if p.Status == Completed || p.Status == Failed {
@@ -83,6 +147,14 @@ func (p *Pipeline) SetDuration() {
 	}
 }
 
+// Localize converts the run_at of the pipeline in place to the timezone of the pipeline, if it has one.
+func (p *Pipeline) Localize() {
+	if p.RunAt != nil && p.PipelineOptions != nil && p.PipelineOptions.Timezone != "" {
+		runAt := schedule.In(*p.RunAt, p.PipelineOptions.Timezone)
+		p.RunAt = &runAt
+	}
+}
+
 // Validate performs basic sanity checks on the pipeline request payload.
 func (p *Pipeline) Validate() error {
 	var required []string
@@ -99,10 +171,46 @@ func (p *Pipeline) Validate() error {
 		return fmt.Errorf(strings.Join(required, ", ") + " required")
 	}
 
+	if err := ValidatePriority(p.Priority); err != nil {
+		return err
+	}
+
+	if p.PipelineOptions != nil && p.PipelineOptions.Cron != "" {
+		if err := schedule.Validate(p.PipelineOptions.Cron); err != nil {
+			return fmt.Errorf("%s is not a valid cron expression: %s", p.PipelineOptions.Cron, err)
+		}
+	}
+
+	if p.PipelineOptions != nil {
+		if err := ValidateConcurrencyPolicy(p.PipelineOptions.ConcurrencyPolicy); err != nil {
+			return err
+		}
+		if err := ValidateMisfirePolicy(p.PipelineOptions.MisfirePolicy, p.PipelineOptions.MisfireLimit); err != nil {
+			return err
+		}
+		if _, err := schedule.LoadLocation(p.PipelineOptions.Timezone); err != nil {
+			return fmt.Errorf("%s is not a valid timezone: %s", p.PipelineOptions.Timezone, err)
+		}
+	}
+
 	if len(p.Jobs) == 1 {
 		return fmt.Errorf("pipeline shoud have at least 2 jobs, %d given", len(p.Jobs))
 	}
 
+	if p.IsDAG() {
+		if _, err := SortSteps(p.Jobs); err != nil {
+			return err
+		}
+	} else {
+		upstream := make(map[string]bool, len(p.Jobs))
+		for _, j := range p.Jobs {
+			if err := j.validateLookups(upstream); err != nil {
+				return err
+			}
+			upstream[j.Name] = true
+		}
+	}
+
 	if p.Status != Undefined {
 		err := p.Status.Validate()
 		if err != nil {
@@ -116,6 +224,14 @@ func (p *Pipeline) IsScheduled() bool {
 	return p.RunAt != nil
 }
 
+// IsRecurring checks if the pipeline runs again once it's done.
+func (p *Pipeline) IsRecurring() bool {
+	if p.PipelineOptions != nil {
+		return p.PipelineOptions.EnableInterval || p.PipelineOptions.Cron != ""
+	}
+	return false
+}
+
 func (p *Pipeline) IsDisabled() bool {
 	if p.PipelineOptions != nil {
 		return p.PipelineOptions.Disable
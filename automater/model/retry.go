@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// RetryFixed waits retry_delay_in_sec between every attempt.
+	RetryFixed = "fixed"
+	// RetryExponential doubles the delay after every attempt, up to retry_max_delay_in_sec.
+	RetryExponential = "exponential"
+
+	// DefaultMaxAttempts is the number of attempts of a job with retries enabled and no max_attempts.
+	DefaultMaxAttempts = 3
+	// DefaultRetryDelay is the delay in seconds before the first retry of a job with no retry_delay_in_sec.
+	DefaultRetryDelay = 10
+)
+
+// MaxRetryAttempts returns the number of attempts of the job, including the first one.
+func (o *JobOptions) MaxRetryAttempts() int {
+	if o == nil || !o.EnableOnFailRetry {
+		return 1
+	}
+	if o.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return o.MaxAttempts
+}
+
+// RetryDelayAfter returns how long to wait before the next attempt, after the given failed attempt.
+func (o *JobOptions) RetryDelayAfter(attempt int) time.Duration {
+	base := DefaultRetryDelay
+	if o != nil && o.RetryDelay > 0 {
+		base = o.RetryDelay
+	}
+	delay := time.Duration(base) * time.Second
+	if o != nil && o.RetryBackoff == RetryExponential && attempt > 1 {
+		delay = time.Duration(float64(delay) * math.Pow(2, float64(attempt-1)))
+	}
+	if o != nil && o.RetryMaxDelay > 0 {
+		maxDelay := time.Duration(o.RetryMaxDelay) * time.Second
+		// A huge exponent overflows to a negative duration.
+		if delay > maxDelay || delay <= 0 {
+			delay = maxDelay
+		}
+	}
+	if o != nil && o.RetryJitter > 0 {
+		// Spread the retries of the jobs that failed together, eg: when a device went offline.
+		delay -= time.Duration(rand.Float64() * o.RetryJitter * float64(delay))
+	}
+	return delay
+}
+
+// validateRetry checks the retry policy of the job options.
+func (o *JobOptions) validateRetry() error {
+	if o.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts should be positive, %d given", o.MaxAttempts)
+	}
+	if o.RetryDelay < 0 || o.RetryMaxDelay < 0 {
+		return fmt.Errorf("retry delays should be positive")
+	}
+	switch o.RetryBackoff {
+	case "", RetryFixed, RetryExponential:
+	default:
+		return fmt.Errorf("%s is not a valid retry backoff - valid backoffs: %s, %s", o.RetryBackoff, RetryFixed, RetryExponential)
+	}
+	if o.RetryJitter < 0 || o.RetryJitter > 1 {
+		return fmt.Errorf("retry_jitter should be between 0 and 1, %v given", o.RetryJitter)
+	}
+	return nil
+}
+
+// CanRetry reports whether the job has some attempts left after a failure.
+func (j *Job) CanRetry() bool {
+	return !j.noRetry && j.Attempt < j.JobOptions.MaxRetryAttempts()
+}
+
+// MarkFailedForGood updates the status of a job failing for a reason another attempt doesn't fix, eg: a template
+// without value, the attempts left are given up.
+func (j *Job) MarkFailedForGood(failedAt *time.Time, reason string) {
+	j.MarkFailed(failedAt, reason)
+	j.noRetry = true
+}
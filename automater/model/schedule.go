@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ScheduledRun is an upcoming run of a schedule preview.
+type ScheduledRun struct {
+	RunAt time.Time `json:"run_at"`
+	// InPast flags a run that would be overdue already, it's handled by the misfire policy.
+	InPast bool `json:"in_past,omitempty"`
+	// SameInstant flags a run landing on the same instant as the previous run.
+	SameInstant bool `json:"same_instant,omitempty"`
+}
@@ -21,6 +21,9 @@ type Transaction struct {
 
 	Status JobStatus `json:"status"`
 
+	// Attempt is the attempt of the job run, a job retried on failure has a transaction per attempt.
+	Attempt int `json:"attempt,omitempty"`
+
 	FailureReason string `json:"failure_reason,omitempty"`
 
 	CreatedAt *time.Time `json:"created_at,omitempty"`
@@ -52,6 +55,8 @@ type PublishTransaction struct {
 
 	Status string `json:"status"`
 
+	Attempt int `json:"attempt,omitempty"`
+
 	FailureReason string `json:"failure_reason,omitempty"`
 
 	CreatedAt *time.Time `json:"created_at,omitempty"`
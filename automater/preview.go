@@ -0,0 +1,66 @@
+package automater
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/timeconversion"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+const (
+	// DefaultPreviewCount is the number of runs of a schedule preview without a count.
+	DefaultPreviewCount = 10
+	// MaxPreviewCount is the most runs of a schedule preview.
+	MaxPreviewCount = 100
+)
+
+// PreviewSchedule returns the first count runs of a schedule starting at first, a schedule without a cron expression
+// or an interval runs once. The runs follow one another like a recycled job does, so a first run in the past shows
+// the runs a misfire would miss.
+func PreviewSchedule(first time.Time, runOnInterval, cronExpr, timezone string, count int) ([]model.ScheduledRun, error) {
+	if count == 0 {
+		count = DefaultPreviewCount
+	}
+	if count < 0 || count > MaxPreviewCount {
+		return nil, &apperrors.ResourceValidationErr{
+			Message: fmt.Sprintf("count should be between 1 and %d, %d given", MaxPreviewCount, count)}
+	}
+	loc, err := location(timezone)
+	if err != nil {
+		return nil, err
+	}
+	now := ttime.New().Now()
+	runs := []model.ScheduledRun{{RunAt: first.In(loc), InPast: first.Before(now)}}
+	if cronExpr == "" && runOnInterval == "" {
+		return runs, nil
+	}
+	var step func(time.Time) time.Time
+	r, err := newRecurrence(runOnInterval, cronExpr, timezone)
+	if err == nil {
+		step = r.next
+	} else if _, adjustErr := timeconversion.AdjustTime(first, runOnInterval); cronExpr == "" && adjustErr == nil {
+		// An interval that doesn't move forward fails to recycle the job, show its runs flagged instead.
+		step = func(t time.Time) time.Time {
+			adjusted, _ := timeconversion.AdjustTime(t, runOnInterval)
+			return adjusted.In(loc)
+		}
+	} else {
+		return nil, err
+	}
+	for previous := first; len(runs) < count; {
+		next := step(previous)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, model.ScheduledRun{
+			RunAt:       next,
+			InPast:      next.Before(now),
+			SameInstant: next.Equal(previous),
+		})
+		previous = next
+	}
+	return runs, nil
+}
@@ -0,0 +1,39 @@
+package automater
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviewSchedule(t *testing.T) {
+	first := time.Now().Add(time.Hour).Truncate(time.Minute)
+	runs, err := PreviewSchedule(first, "15 min", "", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, run := range runs {
+		if expected := first.Add(time.Duration(i) * 15 * time.Minute); !run.RunAt.Equal(expected) || run.InPast || run.SameInstant {
+			t.Errorf("run %d: expected %s, got %+v", i, expected, run)
+		}
+	}
+
+	runs, err = PreviewSchedule(first, "0 sec", "", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || !runs[1].SameInstant {
+		t.Errorf("expected the runs of a zero interval to land on the same instant, got %+v", runs)
+	}
+
+	runs, err = PreviewSchedule(first.Add(-2*time.Hour), "", "@hourly", "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !runs[0].InPast || !runs[1].InPast || runs[3].InPast {
+		t.Errorf("expected the first runs to be flagged in the past, got %+v", runs)
+	}
+
+	if _, err := PreviewSchedule(first, "15 min", "", "", MaxPreviewCount+1); err == nil {
+		t.Error("expected a count above the maximum to fail")
+	}
+}
@@ -0,0 +1,101 @@
+package blackoutsrv
+
+import (
+	"fmt"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+)
+
+var _ automater.BlackoutService = &blackoutService{}
+
+type blackoutService struct {
+	storage automater.Storage
+	uuidGen uuid.Generator
+	time    intime.Time
+}
+
+// New creates a new blackout server.
+func New(storage automater.Storage, uuidGen uuid.Generator, time intime.Time) *blackoutService {
+	return &blackoutService{
+		storage: storage,
+		uuidGen: uuidGen,
+		time:    time,
+	}
+}
+
+// Create creates a new blackout.
+func (srv *blackoutService) Create(
+	name, description, timezone string, global bool,
+	weekly []model.WeeklyRange, absolute []model.AbsoluteRange) (*model.Blackout, error) {
+
+	id, err := srv.uuidGen.Make("blk")
+	if err != nil {
+		return nil, err
+	}
+	createdAt := srv.time.Now()
+	b := model.NewBlackout(id, name, description, timezone, global, weekly, absolute, &createdAt)
+	if err := srv.validate(b); err != nil {
+		return nil, err
+	}
+	if err := srv.storage.CreateBlackout(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Get fetches a blackout.
+func (srv *blackoutService) Get(uuid string) (*model.Blackout, error) {
+	return srv.storage.GetBlackout(uuid)
+}
+
+// GetBlackouts fetches all blackouts.
+func (srv *blackoutService) GetBlackouts() ([]*model.Blackout, error) {
+	return srv.storage.GetBlackouts()
+}
+
+// Update replaces the windows of a blackout.
+func (srv *blackoutService) Update(uuid string, body *model.Blackout) (*model.Blackout, error) {
+	b, err := srv.storage.GetBlackout(uuid)
+	if err != nil {
+		return nil, err
+	}
+	body.UUID = b.UUID
+	body.CreatedAt = b.CreatedAt
+	if err := srv.validate(body); err != nil {
+		return nil, err
+	}
+	if err := srv.storage.UpdateBlackout(uuid, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Delete deletes a blackout.
+func (srv *blackoutService) Delete(uuid string) error {
+	_, err := srv.storage.GetBlackout(uuid)
+	if err != nil {
+		return err
+	}
+	return srv.storage.DeleteBlackout(uuid)
+}
+
+// validate checks the blackout and that its name is unique, the jobs and pipelines refer to the blackouts by name.
+func (srv *blackoutService) validate(b *model.Blackout) error {
+	if err := b.Validate(); err != nil {
+		return &apperrors.ResourceValidationErr{Message: err.Error()}
+	}
+	blackouts, err := srv.storage.GetBlackouts()
+	if err != nil {
+		return err
+	}
+	for _, existing := range blackouts {
+		if existing.UUID != b.UUID && existing.Name == b.Name {
+			return &apperrors.ResourceValidationErr{Message: fmt.Sprintf("blackout %s already exists", b.Name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package deadlettersrv
+
+import (
+	"fmt"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+var _ automater.DeadLetterService = &deadLetterService{}
+
+type deadLetterService struct {
+	jobQueue automater.JobQueue
+	storage  automater.Storage
+	notifier automater.Notifier
+}
+
+// New creates a new dead letter server.
+func New(jobQueue automater.JobQueue, storage automater.Storage, notifier automater.Notifier) *deadLetterService {
+	return &deadLetterService{
+		jobQueue: jobQueue,
+		storage:  storage,
+		notifier: notifier,
+	}
+}
+
+// GetDeadLetters fetches all dead letters.
+func (srv *deadLetterService) GetDeadLetters() ([]*model.DeadLetter, error) {
+	return srv.jobQueue.GetDeadLetters()
+}
+
+// Get fetches a dead letter.
+func (srv *deadLetterService) Get(uuid string) (*model.DeadLetter, error) {
+	return srv.jobQueue.GetDeadLetter(uuid)
+}
+
+// Requeue pushes the job of a dead letter back to the job queue and deletes the dead letter.
+func (srv *deadLetterService) Requeue(uuid string) (*model.Job, error) {
+	d, err := srv.jobQueue.GetDeadLetter(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if d.Job == nil {
+		return nil, &apperrors.ResourceValidationErr{
+			Message: fmt.Sprintf("dead letter with UUID: %s has no decodable job, it can only be deleted", uuid)}
+	}
+	j, err := srv.storage.GetJob(d.Job.UUID)
+	if err != nil {
+		return nil, err
+	}
+	previous := *j
+	// The job goes straight to the queue, it's scheduled rather than due so the scheduler doesn't push it again.
+	scheduledAt := ttime.New().Now()
+	j.MarkScheduled(&scheduledAt)
+	j.FailureReason = ""
+	// A requeued job gets all of its attempts again.
+	j.Attempt = 0
+	if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+		return nil, err
+	}
+	if err := srv.jobQueue.Push(j); err != nil {
+		// Keep the job as it was, the dead letter stays for another attempt.
+		if _, err := srv.storage.UpdateJob(previous.UUID, &previous); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	srv.notifier.NotifyQueued()
+	if err := srv.jobQueue.DeleteDeadLetter(uuid); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Delete deletes a dead letter.
+func (srv *deadLetterService) Delete(uuid string) error {
+	_, err := srv.jobQueue.GetDeadLetter(uuid)
+	if err != nil {
+		return err
+	}
+	return srv.jobQueue.DeleteDeadLetter(uuid)
+}
+
+// Purge deletes all dead letters.
+func (srv *deadLetterService) Purge() error {
+	return srv.jobQueue.PurgeDeadLetters()
+}
@@ -0,0 +1,46 @@
+package deadlettersrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/jobqueue"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+)
+
+func TestDeadLetterService_RequeueRunsOnce(t *testing.T) {
+	storage := memory.New()
+	queue := jobqueue.NewMemoryQueue(10, "text")
+	srv := New(queue, storage, wakeup.New())
+
+	now := time.Now()
+	runAt := now.Add(-time.Minute)
+	j := model.NewJob("job_1", "ping", "task", "", "", "", "", 0, &runAt, &now, false, false, nil, nil)
+	j.MarkFailed(&now, "boom")
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.PushDeadLetter(model.NewDeadLetter("dl_1", j, "", "boom", &now)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := srv.Requeue("dl_1"); err != nil {
+		t.Fatal(err)
+	}
+	if popped := queue.Pop(); popped == nil || popped.UUID != "job_1" {
+		t.Fatalf("expected job_1 to be queued, got %v", popped)
+	}
+	// The scheduler must not push it a second time.
+	due, err := storage.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("expected the requeued job not to be due, got %v", due)
+	}
+	if _, err := queue.GetDeadLetter("dl_1"); err == nil {
+		t.Fatal("expected the dead letter to be deleted")
+	}
+}
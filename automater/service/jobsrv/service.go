@@ -7,6 +7,7 @@ import (
 	"github.com/NubeIO/rubix-automater/automater/model"
 	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/schedule"
 	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
 	"strings"
@@ -14,34 +15,48 @@ import (
 
 var _ automater.JobService = &jobService{}
 
+// cancelledReason is the failure reason of the cancelled jobs.
+const cancelledReason = "cancelled"
+
 type jobService struct {
-	storage  automater.Storage
-	taskRepo *taskRepo.TaskRepository
-	uuidGen  uuid.Generator
-	time     intime.Time
+	storage     automater.Storage
+	notifier    automater.Notifier
+	workService automater.WorkService
+	taskRepo    *taskRepo.TaskRepository
+	uuidGen     uuid.Generator
+	time        intime.Time
 }
 
 // New creates a new job server.
 func New(
 	storage automater.Storage,
+	notifier automater.Notifier,
+	workService automater.WorkService,
 	taskRepo *taskRepo.TaskRepository,
 	uuidGen uuid.Generator,
 	time intime.Time) *jobService {
 	return &jobService{
-		storage:  storage,
-		taskRepo: taskRepo,
-		uuidGen:  uuidGen,
-		time:     time,
+		storage:     storage,
+		notifier:    notifier,
+		workService: workService,
+		taskRepo:    taskRepo,
+		uuidGen:     uuidGen,
+		time:        time,
 	}
 }
 
 // Create creates a new job.
 func (srv *jobService) Create(
-	name, taskName, subTaskName, description string, scheduleAt string,
-	timeout int, disable bool, options *model.JobOptions, taskParams map[string]interface{}) (*model.Job, error) {
+	name, taskName, subTaskName, description string, scheduleAt, queue string,
+	timeout, priority int, disable bool, options *model.JobOptions, taskParams map[string]interface{}) (*model.Job, error) {
 	id, _ := srv.uuidGen.Make("job")
 
-	runAt, err := automater.RunAt(scheduleAt)
+	var timezone string
+	if options != nil {
+		scheduleAt = automater.CronScheduleAt(scheduleAt, options.Cron, options.Timezone)
+		timezone = options.Timezone
+	}
+	runAt, err := automater.RunAt(scheduleAt, timezone)
 	if err != nil {
 		return nil, err
 	}
@@ -53,14 +68,26 @@ func (srv *jobService) Create(
 		id, name, taskName, subTaskName, description,
 		"", "", timeout, &runAt,
 		&createdAt, false, disable, options, taskParams)
+	j.Priority = priority
+	j.Queue = queue
 
 	if err := j.Validate(srv.taskRepo); err != nil {
 		return nil, &apperrors.ResourceValidationErr{Message: err.Error()}
 	}
+	if !srv.workService.HasQueue(queue) {
+		return nil, &apperrors.ResourceValidationErr{Message: fmt.Sprintf("queue %s is not served by any worker pool", queue)}
+	}
+	if options != nil {
+		if err := automater.CheckBlackouts(srv.storage, options.Blackouts); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := srv.storage.CreateJob(j); err != nil {
 		return nil, err
 	}
+	srv.notifier.NotifyDue(runAt)
+	j.Localize()
 	return j, nil
 }
 
@@ -71,6 +98,7 @@ func (srv *jobService) Get(uuid string) (*model.Job, error) {
 		return nil, err
 	}
 	j.SetDuration()
+	j.Localize()
 	// Do not marshal job next, because it's stored in NoSQL databases.
 	j.Next = nil
 	return j, nil
@@ -93,19 +121,44 @@ func (srv *jobService) GetJobs(status string) ([]*model.Job, error) {
 	}
 	for _, j := range jobs {
 		j.SetDuration()
+		j.Localize()
 		// Do not marshal job next, cause it's stored in NoSQL databases.
 		j.Next = nil
 	}
 	return jobs, nil
 }
 
-// Update updates a job.
+// Update updates the name, description, disable flag and options of a job, the name and description are kept if
+// empty and the options if nil.
 func (srv *jobService) Update(uuid string, body *model.Job) (*model.Job, error) {
-	_, err := srv.storage.GetJob(uuid)
+	j, err := srv.storage.GetJob(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if body.Name != "" {
+		j.Name = body.Name
+	}
+	if body.Description != "" {
+		j.Description = body.Description
+	}
+	j.Disable = body.Disable
+	if body.JobOptions != nil {
+		if err := body.JobOptions.Validate(); err != nil {
+			return nil, &apperrors.ResourceValidationErr{Message: err.Error()}
+		}
+		if err := automater.CheckBlackouts(srv.storage, body.JobOptions.Blackouts); err != nil {
+			return nil, err
+		}
+		j.JobOptions = body.JobOptions
+	}
+	j, err = srv.storage.UpdateJob(uuid, j)
 	if err != nil {
 		return nil, err
 	}
-	return srv.storage.UpdateJob(uuid, body)
+	if j.IsScheduled() {
+		srv.notifier.NotifyDue(*j.RunAt)
+	}
+	return j, nil
 }
 
 // Recycle reuse a job.
@@ -114,7 +167,14 @@ func (srv *jobService) Recycle(uuid string, body *model.Job) (*model.Job, error)
 	if err != nil {
 		return nil, err
 	}
-	return srv.storage.Recycle(uuid, body)
+	j, err := srv.storage.Recycle(uuid, body)
+	if err != nil {
+		return nil, err
+	}
+	if j.IsScheduled() {
+		srv.notifier.NotifyDue(*j.RunAt)
+	}
+	return j, nil
 }
 
 // Delete deletes a job.
@@ -134,6 +194,52 @@ func (srv *jobService) Delete(uuid string) error {
 	return srv.storage.DeleteJob(uuid)
 }
 
+// Cancel cancels a job waiting to run or running. A recurring job carries on with its next run, the others stay
+// CANCELLED.
+func (srv *jobService) Cancel(uuid string) (*model.Job, error) {
+	j, err := srv.storage.GetJob(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if j.BelongsToPipeline() {
+		return nil, &apperrors.ResourceValidationErr{
+			Message: fmt.Sprintf("job with UUID: %s belongs to a pipeline - try to cancel the pipeline instead", uuid)}
+	}
+	if !j.IsActive() {
+		return nil, &apperrors.ResourceValidationErr{
+			Message: fmt.Sprintf("job with UUID: %s can not be cancelled, its status is %s", uuid, j.Status)}
+	}
+	// A job still waiting for its run isn't held by a worker, a dispatched one is dropped by the worker picking it up.
+	dispatched := j.Status != model.Pending
+	cancelledAt := srv.time.Now()
+	running := srv.workService.Cancel(j, cancelledReason)
+	j.MarkCancelled(&cancelledAt, cancelledReason)
+	if !running {
+		// Otherwise the worker stores the cancelled run.
+		if _, err := srv.storage.CreateTransaction(j); err != nil {
+			return nil, err
+		}
+		if j.IsRecycleJob() && !dispatched {
+			// The recurring job carries on with its next run.
+			if err := automater.SkipRunAt(j); err != nil {
+				return nil, err
+			}
+			if j, err = srv.storage.Recycle(uuid, j); err != nil {
+				return nil, err
+			}
+			if j.RunAt != nil {
+				srv.notifier.NotifyDue(*j.RunAt)
+			}
+		} else if j, err = srv.storage.UpdateJob(uuid, j); err != nil {
+			return nil, err
+		}
+		// A worker may have picked the job up meanwhile, it stores the cancelled run then.
+		srv.workService.Cancel(j, cancelledReason)
+	}
+	j.Localize()
+	return j, nil
+}
+
 func (srv *jobService) Drop() error {
 	jobs, err := srv.GetJobs("")
 	if err != nil {
@@ -144,3 +250,41 @@ func (srv *jobService) Drop() error {
 	}
 	return nil
 }
+
+// Schedule previews the next runs of a job, starting from its run_at.
+func (srv *jobService) Schedule(uuid string, count int) ([]model.ScheduledRun, error) {
+	j, err := srv.storage.GetJob(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if j.RunAt == nil {
+		return nil, &apperrors.ResourceValidationErr{Message: fmt.Sprintf("job with UUID: %s has no run_at", uuid)}
+	}
+	var runOnInterval, cronExpr, timezone string
+	if j.JobOptions != nil {
+		timezone = j.JobOptions.Timezone
+		if j.IsRecycleJob() {
+			runOnInterval = j.JobOptions.RunOnInterval
+			cronExpr = j.JobOptions.Cron
+		}
+	}
+	return automater.PreviewSchedule(*j.RunAt, runOnInterval, cronExpr, timezone, count)
+}
+
+// PreviewSchedule previews the runs of a schedule before creating a job, the run_at is read like the schedule_at of
+// a new job.
+func (srv *jobService) PreviewSchedule(
+	runAt, runOnInterval, cronExpr, timezone string, count int) ([]model.ScheduledRun, error) {
+
+	if cronExpr != "" {
+		if err := schedule.Validate(cronExpr); err != nil {
+			return nil, &apperrors.ResourceValidationErr{
+				Message: fmt.Sprintf("%s is not a valid cron expression: %s", cronExpr, err)}
+		}
+	}
+	first, err := automater.RunAt(automater.CronScheduleAt(runAt, cronExpr, timezone), timezone)
+	if err != nil {
+		return nil, err
+	}
+	return automater.PreviewSchedule(first, runOnInterval, cronExpr, timezone, count)
+}
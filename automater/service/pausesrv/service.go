@@ -0,0 +1,70 @@
+package pausesrv
+
+import (
+	"fmt"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+var _ automater.PauseService = &pauseService{}
+
+type pauseService struct {
+	storage     automater.Storage
+	notifier    automater.Notifier
+	workService automater.WorkService
+	time        intime.Time
+}
+
+// New creates a new pause server.
+func New(storage automater.Storage, notifier automater.Notifier, workService automater.WorkService, time intime.Time) *pauseService {
+	return &pauseService{
+		storage:     storage,
+		notifier:    notifier,
+		workService: workService,
+		time:        time,
+	}
+}
+
+// Get fetches the paused state of the scheduler.
+func (srv *pauseService) Get() (*model.Pause, error) {
+	return srv.storage.GetPause()
+}
+
+// Pause pauses the scheduling and the dispatching of the queue, or of every queue if the queue is empty.
+func (srv *pauseService) Pause(queue string) (*model.Pause, error) {
+	if !srv.workService.HasQueue(queue) {
+		return nil, &apperrors.ResourceValidationErr{Message: fmt.Sprintf("queue %s is not served by any worker pool", queue)}
+	}
+	pausedAt := srv.time.Now()
+	return srv.storage.UpdatePause(func(p *model.Pause) {
+		p.PauseQueue(queue, &pausedAt)
+	})
+}
+
+// Resume resumes the queue, or lifts the global pause if the queue is empty. The work that got due in the meantime
+// is scheduled and dispatched right away. A paused queue no worker pool serves anymore can still be resumed.
+func (srv *pauseService) Resume(queue string) (*model.Pause, error) {
+	if !srv.workService.HasQueue(queue) {
+		p, err := srv.storage.GetPause()
+		if err != nil {
+			return nil, err
+		}
+		if !p.IsQueuePaused(queue) {
+			return nil, &apperrors.ResourceValidationErr{
+				Message: fmt.Sprintf("queue %s is not served by any worker pool", queue)}
+		}
+	}
+	resumedAt := srv.time.Now()
+	p, err := srv.storage.UpdatePause(func(p *model.Pause) {
+		p.ResumeQueue(queue, &resumedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	srv.notifier.NotifyDue(resumedAt)
+	srv.notifier.NotifyQueued()
+	return p, nil
+}
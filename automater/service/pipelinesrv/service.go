@@ -2,6 +2,7 @@ package pipelinesrv
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/automater/model"
 	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
@@ -14,33 +15,54 @@ import (
 
 var _ automater.PipelineService = &pipeLineService{}
 
+// cancelledReason is the failure reason of the cancelled pipeline jobs.
+const cancelledReason = "cancelled"
+
 type pipeLineService struct {
-	storage  automater.Storage
-	taskRepo *taskRepo.TaskRepository
-	uuidGen  uuid.Generator
-	time     ttime.Time
+	storage     automater.Storage
+	notifier    automater.Notifier
+	workService automater.WorkService
+	taskRepo    *taskRepo.TaskRepository
+	uuidGen     uuid.Generator
+	time        ttime.Time
 }
 
 // New creates a new pipeline server.
 func New(
 	storage automater.Storage,
+	notifier automater.Notifier,
+	workService automater.WorkService,
 	taskRepo *taskRepo.TaskRepository,
 	uuidGen uuid.Generator,
 	time ttime.Time) *pipeLineService {
 	return &pipeLineService{
-		storage:  storage,
-		taskRepo: taskRepo,
-		uuidGen:  uuidGen,
-		time:     time,
+		storage:     storage,
+		notifier:    notifier,
+		workService: workService,
+		taskRepo:    taskRepo,
+		uuidGen:     uuidGen,
+		time:        time,
 	}
 }
 
 // Create creates a new pipeline.
-func (srv *pipeLineService) Create(name, description, scheduleAt string, pipelineOptions *model.PipelineOptions, jobs []*model.Job) (*model.Pipeline, error) {
+func (srv *pipeLineService) Create(name, description, scheduleAt, queue string, priority int, pipelineOptions *model.PipelineOptions, jobs []*model.Job, vars map[string]interface{}) (*model.Pipeline, error) {
 	pipelineUUID, err := srv.uuidGen.Make("pip")
 	if err != nil {
 		return nil, err
 	}
+	if !srv.workService.HasQueue(queue) {
+		return nil, &apperrors.ResourceValidationErr{Message: fmt.Sprintf("queue %s is not served by any worker pool", queue)}
+	}
+	dag := model.IsDAG(jobs)
+	var upstreams map[string][]model.Upstream
+	if dag {
+		// The steps run as soon as the steps they wait for ended, so the first step is always one to start with.
+		if jobs, err = model.SortSteps(jobs); err != nil {
+			return nil, &apperrors.ResourceValidationErr{Message: err.Error()}
+		}
+		upstreams = model.Upstreams(jobs)
+	}
 	jobIDs := make([]string, 0)
 	for i := 0; i < len(jobs); i++ { //make the pipeline jobs
 		jobUUID, err := srv.uuidGen.Make("job")
@@ -49,49 +71,107 @@ func (srv *pipeLineService) Create(name, description, scheduleAt string, pipelin
 		}
 		jobIDs = append(jobIDs, jobUUID)
 	}
+	if pipelineOptions != nil {
+		scheduleAt = automater.CronScheduleAt(scheduleAt, pipelineOptions.Cron, pipelineOptions.Timezone)
+	}
 	jobsToCreate := make([]*model.Job, 0)
 	for i, job := range jobs {
-		now, err := automater.PipelineRunAt(scheduleAt, pipelineOptions, i)
+		index := i
+		if dag {
+			// The steps of a graph don't wait for each other but for their dependencies.
+			index = 0
+		}
+		now, err := automater.PipelineRunAt(scheduleAt, pipelineOptions, index)
 		runAtTime := now.Add(time.Millisecond * time.Duration(i+2)) // in db GetDueJobs it orders by time desc, so we need a small buffer (this is a hack)
 		if err != nil {
 			return nil, err
 		}
 		jobID := jobIDs[i]
 		nextJobID := ""
-		if i < len(jobs)-1 {
+		if i < len(jobs)-1 && !dag {
 			nextJobID = jobIDs[i+1]
 		}
 		createdAt := srv.time.Now()
 		j := model.NewJob(
 			jobID, job.Name, job.TaskName, job.SubTaskName, job.Description, pipelineUUID, nextJobID,
 			job.Timeout, &runAtTime, &createdAt, job.UsePreviousResults, job.Disable, job.JobOptions, job.TaskParams)
+		j.Priority = priority
+		j.Queue = queue
+		j.DependsOn = job.DependsOn
+		j.When = job.When
+		j.OnSuccess = job.OnSuccess
+		j.OnFailure = job.OnFailure
+		if pipelineOptions != nil {
+			// The jobs are scheduled by the pipeline, so they run in its timezone and with its concurrency policy.
+			if j.JobOptions == nil {
+				j.JobOptions = &model.JobOptions{}
+			}
+			if pipelineOptions.Timezone != "" {
+				j.JobOptions.Timezone = pipelineOptions.Timezone
+			}
+			if pipelineOptions.ConcurrencyPolicy != "" {
+				j.JobOptions.ConcurrencyPolicy = pipelineOptions.ConcurrencyPolicy
+			}
+		}
 		if err := j.Validate(srv.taskRepo); err != nil {
 			return nil, &apperrors.ResourceValidationErr{Message: err.Error()}
 		}
+		if j.JobOptions != nil {
+			if err := automater.CheckBlackouts(srv.storage, j.JobOptions.Blackouts); err != nil {
+				return nil, err
+			}
+		}
+		if len(upstreams[j.Name]) > 0 {
+			// The step is due once the steps it waits for ended.
+			j.RunAt = nil
+		}
 		jobsToCreate = append(jobsToCreate, j)
 	}
 	createdAt := srv.time.Now()
 	p := model.NewPipeline(pipelineUUID, name, description, pipelineOptions, jobsToCreate, &createdAt)
+	p.Priority = priority
+	p.Queue = queue
+	p.Vars = vars
 
 	if err := p.Validate(); err != nil {
 		return nil, &apperrors.ResourceValidationErr{Message: err.Error()}
 	}
+	if pipelineOptions != nil {
+		if err := automater.CheckBlackouts(srv.storage, pipelineOptions.Blackouts); err != nil {
+			return nil, err
+		}
+	}
 	// Inherit first job's schedule timestamp.
 	p.RunAt = jobsToCreate[0].RunAt
 
 	if err := srv.storage.CreatePipeline(p); err != nil {
 		return nil, err
 	}
+	srv.notifier.NotifyDue(*p.RunAt)
+	p.Localize()
 	return p, nil
 }
 
 func (srv *pipeLineService) RecyclePipeline(uuid string, p *model.Pipeline) (*model.Pipeline, error) {
-	return srv.storage.RecyclePipeline(uuid, p)
-
+	recycled, err := srv.storage.RecyclePipeline(uuid, p)
+	if err != nil {
+		return nil, err
+	}
+	if recycled.IsScheduled() {
+		srv.notifier.NotifyDue(*recycled.RunAt)
+	}
+	return recycled, nil
 }
 
 func (srv *pipeLineService) RecycleJob(uuid string, p *model.Job) (*model.Job, error) {
-	return srv.storage.Recycle(uuid, p)
+	recycled, err := srv.storage.Recycle(uuid, p)
+	if err != nil {
+		return nil, err
+	}
+	if recycled.IsScheduled() {
+		srv.notifier.NotifyDue(*recycled.RunAt)
+	}
+	return recycled, nil
 }
 
 // Get fetches a pipeline.
@@ -101,6 +181,7 @@ func (srv *pipeLineService) Get(uuid string) (*model.Pipeline, error) {
 		return nil, err
 	}
 	p.SetDuration()
+	p.Localize()
 	// Do not marshal pipeline jobs cause NoSQL databases
 	// store them along with the pipeline.
 	p.Jobs = nil
@@ -120,6 +201,7 @@ func (srv *pipeLineService) GetPipelineJobs(uuid string) ([]*model.Job, error) {
 
 	for _, j := range jobs {
 		j.SetDuration()
+		j.Localize()
 		j.Next = nil
 	}
 	return jobs, nil
@@ -142,6 +224,7 @@ func (srv *pipeLineService) GetPipelines(status string) ([]*model.Pipeline, erro
 	}
 	for _, p := range pipelines {
 		p.SetDuration()
+		p.Localize()
 		// Do not marshal pipeline jobs cause NoSQL databases
 		// store them along with the pipeline.
 		p.Jobs = nil
@@ -169,3 +252,55 @@ func (srv *pipeLineService) Delete(uuid string) error {
 	}
 	return srv.storage.DeletePipeline(uuid)
 }
+
+// Cancel cancels a pipeline waiting to run or running, the step in progress is stopped and the next steps don't run.
+// The pipeline stays CANCELLED until it's recycled.
+func (srv *pipeLineService) Cancel(uuid string) (*model.Pipeline, error) {
+	p, err := srv.storage.GetPipeline(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != model.Pending && p.Status != model.Scheduled && p.Status != model.InProgress {
+		return nil, &apperrors.ResourceValidationErr{
+			Message: fmt.Sprintf("pipeline with UUID: %s can not be cancelled, its status is %s", uuid, p.Status)}
+	}
+	jobs, err := srv.storage.GetJobsByPipelineID(uuid)
+	if err != nil {
+		return nil, err
+	}
+	// The runs of a pipeline are registered by the job they started from.
+	running := false
+	for _, j := range jobs {
+		if srv.workService.Cancel(j, cancelledReason) {
+			running = true
+		}
+	}
+	cancelledAt := srv.time.Now()
+	for _, j := range jobs {
+		if !j.IsActive() || (running && j.Status == model.InProgress) {
+			// The worker stores the cancelled step.
+			continue
+		}
+		j.MarkCancelled(&cancelledAt, cancelledReason)
+		if _, err := srv.storage.CreateTransaction(j); err != nil {
+			return nil, err
+		}
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			return nil, err
+		}
+	}
+	p, err = srv.storage.GetPipeline(uuid)
+	if err != nil {
+		return nil, err
+	}
+	p.MarkCancelled(&cancelledAt)
+	if err := srv.storage.UpdatePipeline(uuid, p); err != nil {
+		return nil, err
+	}
+	// A worker may have picked a step up meanwhile, it stores the cancelled step then.
+	for _, j := range jobs {
+		srv.workService.Cancel(j, cancelledReason)
+	}
+	p.Localize()
+	return p, nil
+}
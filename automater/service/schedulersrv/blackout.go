@@ -0,0 +1,70 @@
+package schedulersrv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// blackout defers a due job falling in a blackout window to the end of the window, the deferral is recorded as a
+// DEFERRED transaction. The pending jobs of a pipeline coming after the job are deferred along, so they keep their
+// order and delays. The run_at the job or the pipeline was due at stays the anchor of its schedule, so a recurring
+// one doesn't drift off its grid. It returns true if the job got deferred.
+func (srv *schedulerService) blackout(j *model.Job, p *model.Pipeline, blackouts []*model.Blackout) bool {
+	if len(blackouts) == 0 {
+		return false
+	}
+	var attached []string
+	if j.JobOptions != nil {
+		attached = append(attached, j.JobOptions.Blackouts...)
+	}
+	if p != nil && p.PipelineOptions != nil {
+		attached = append(attached, p.PipelineOptions.Blackouts...)
+	}
+	now := srv.time.Now()
+	b, end, ok := automater.ActiveBlackout(blackouts, attached, now)
+	if !ok {
+		return false
+	}
+	reason := fmt.Sprintf("deferred by blackout %s until %s", b.Name, end.Format(time.RFC3339))
+	srv.logger.Infof("%s, job uuid: %s", reason, j.UUID)
+	deferred := *j
+	deferred.MarkDeferred(&now, reason)
+	if _, err := srv.storage.CreateTransaction(&deferred); err != nil {
+		srv.logger.Errorf("could not create transaction: %s", err)
+	}
+
+	if p == nil {
+		j.Postpone(&end)
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			srv.logger.Errorf("could not update job: %s", err)
+		}
+		return true
+	}
+	runAt := *j.RunAt
+	delay := end.Sub(runAt)
+	jobs, err := srv.storage.GetJobsByPipelineID(p.UUID)
+	if err != nil {
+		srv.logger.Errorf("could not get pipeline jobs from storage: %s", err)
+		return true
+	}
+	for _, pj := range jobs {
+		if pj.Status != model.Pending || pj.RunAt == nil || pj.RunAt.Before(runAt) {
+			continue
+		}
+		deferredAt := pj.RunAt.Add(delay)
+		pj.RunAt = &deferredAt
+		if _, err := srv.storage.UpdateJob(pj.UUID, pj); err != nil {
+			srv.logger.Errorf("could not update job: %s", err)
+		}
+	}
+	if p.Status == model.Pending && p.RunAt != nil && p.RunAt.Equal(runAt) {
+		p.Postpone(&end)
+		if err := srv.storage.UpdatePipeline(p.UUID, p); err != nil {
+			srv.logger.Errorf("could not update pipeline: %s", err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package schedulersrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduler_Blackout(t *testing.T) {
+	storage := memory.New()
+	srv := New(nil, storage, nil, wakeup.New(), intime.New(), time.Minute, logrus.New())
+
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	b := &model.Blackout{
+		UUID:      "blk_1",
+		Name:      "maintenance",
+		Absolute:  []model.AbsoluteRange{{Start: &start, End: &end}},
+		CreatedAt: &now,
+	}
+	if err := storage.CreateBlackout(b); err != nil {
+		t.Fatal(err)
+	}
+	blackouts, _ := storage.GetBlackouts()
+
+	free := &model.Job{UUID: "job_free", Name: "poll", TaskName: "poll", Status: model.Pending, RunAt: &now}
+	if srv.blackout(free, nil, blackouts) {
+		t.Fatal("expected a job without blackouts to run")
+	}
+
+	j := &model.Job{
+		UUID:       "job_1",
+		Name:       "poll",
+		TaskName:   "poll",
+		Status:     model.Pending,
+		RunAt:      &now,
+		JobOptions: &model.JobOptions{Blackouts: []string{"maintenance"}},
+	}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	if !srv.blackout(j, nil, blackouts) {
+		t.Fatal("expected the job to be deferred")
+	}
+	stored, _ := storage.GetJob(j.UUID)
+	if stored.Status != model.Pending || !stored.RunAt.Equal(end) {
+		t.Fatalf("expected the job to be pending until %s, got %s at %s", end, stored.Status, stored.RunAt)
+	}
+	if stored.ScheduleAnchor == nil || !stored.ScheduleAnchor.Equal(now) {
+		t.Fatalf("expected the schedule to stay anchored at %s, got %v", now, stored.ScheduleAnchor)
+	}
+	transactions, _ := storage.GetTransactionsByJob(j.UUID)
+	if len(transactions) != 1 || transactions[0].Status != model.Deferred {
+		t.Fatalf("expected a deferred transaction, got %+v", transactions)
+	}
+}
@@ -0,0 +1,107 @@
+package schedulersrv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// leaseIntervals is how many intervals the leadership and the job leases claimed by the leader last, so a slow
+// renewal doesn't hand the leadership over.
+const leaseIntervals = 3
+
+// leadership is the scheduler leadership of this instance.
+type leadership struct {
+	mu    sync.Mutex
+	owner string
+	ttl   time.Duration
+	// token is the fencing token of the leadership, zero when this instance isn't the leader.
+	token int64
+	// until is when the leadership lapses if it's not renewed, this instance steps down then.
+	until time.Time
+}
+
+// Lead campaigns for the scheduler leadership in the given interval until the context is done, so only one of the
+// automater instances sharing a storage schedules the due jobs. The leader claims the lease of every due job before
+// dispatching it. Without campaign the scheduler leads on its own and doesn't claim the jobs.
+func (srv *schedulerService) Lead(ctx context.Context, owner string, interval time.Duration) {
+	srv.leader = &leadership{owner: owner, ttl: leaseIntervals * interval}
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			srv.campaign()
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				if err := srv.storage.ReleaseLeadership(owner); err != nil {
+					srv.logger.Errorf("could not release the scheduler leadership: %s", err)
+				}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// campaign claims or extends the leadership, the scheduler is woken up when this instance takes the lead.
+func (srv *schedulerService) campaign() {
+	l := srv.leader
+	now := srv.time.Now()
+	until := now.Add(l.ttl)
+	token, acquired, err := srv.storage.AcquireLeadership(l.owner, until)
+	if err != nil {
+		// Keep the lead until it lapses, the storage may be back by then.
+		srv.logger.Errorf("could not acquire the scheduler leadership: %s", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !acquired {
+		if l.token != 0 {
+			srv.logger.Warnf("instance %s lost the scheduler leadership", l.owner)
+		}
+		l.token = 0
+		return
+	}
+	if l.token != token {
+		srv.logger.Infof("instance %s leads the scheduler with token %d", l.owner, token)
+		srv.wakeup.NotifyDue(now)
+	}
+	l.token, l.until = token, until
+}
+
+// lead returns the fencing token of the leadership and false if this instance isn't the leader, the token is zero
+// without campaign.
+func (srv *schedulerService) lead() (int64, bool) {
+	l := srv.leader
+	if l == nil {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == 0 || !srv.time.Now().Before(l.until) {
+		return 0, false
+	}
+	return l.token, true
+}
+
+// claim claims the lease of the due job before its dispatch, it returns false if another instance holds the job or
+// this instance lost the leadership. The lease of a job left behind by a busy worker pool just expires, it may be
+// held by a run in progress already.
+func (srv *schedulerService) claim(j *model.Job, token int64) bool {
+	if srv.leader == nil {
+		return true
+	}
+	expiresAt := srv.time.Now().Add(srv.leader.ttl)
+	claimed, err := srv.storage.ClaimLease(srv.leader.owner, j.UUID, token, expiresAt)
+	if err != nil {
+		srv.logger.Errorf("could not claim the lease of job %s: %s", j.UUID, err)
+		return false
+	}
+	if !claimed {
+		srv.logger.Infof("the lease of job %s is held by another instance, skipping it", j.UUID)
+	}
+	return claimed
+}
@@ -0,0 +1,80 @@
+package schedulersrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeTime is a clock the tests move by hand.
+type fakeTime struct {
+	now time.Time
+}
+
+func (f *fakeTime) Now(...bool) time.Time {
+	return f.now
+}
+
+func TestScheduler_Leadership(t *testing.T) {
+	storage := memory.New()
+	clock := &fakeTime{now: time.Now()}
+	a := New(nil, storage, nil, wakeup.New(), clock, time.Minute, logrus.New())
+	b := New(nil, storage, nil, wakeup.New(), clock, time.Minute, logrus.New())
+	a.leader = &leadership{owner: "a", ttl: time.Minute}
+	b.leader = &leadership{owner: "b", ttl: time.Minute}
+
+	a.campaign()
+	b.campaign()
+	token, leading := a.lead()
+	if !leading {
+		t.Fatal("expected the first instance to lead")
+	}
+	if _, leading := b.lead(); leading {
+		t.Fatal("expected a single leader")
+	}
+
+	now := time.Now()
+	j := &model.Job{UUID: "job_1", Name: "poll", TaskName: "poll", Status: model.Pending, RunAt: &now, CreatedAt: &now}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	if b.schedule() {
+		t.Fatal("expected a follower not to schedule")
+	}
+	if stored, _ := storage.GetJob(j.UUID); stored.Status != model.Pending {
+		t.Fatalf("expected the due job to stay pending on a follower, got %s", stored.Status)
+	}
+	if !a.claim(j, token) {
+		t.Fatal("expected the leader to claim the due job")
+	}
+
+	// The leadership of the first instance lapses without renewal, the second one takes over. The memory storage
+	// expires the leadership and the leases on the wall clock, the last renewals of the first instance lapse there.
+	clock.now = clock.now.Add(2 * time.Minute)
+	lapsed := time.Now().Add(-time.Second)
+	if _, _, err := storage.AcquireLeadership("a", lapsed); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.RenewLeases("a", []string{j.UUID}, lapsed); err != nil {
+		t.Fatal(err)
+	}
+	if _, leading := a.lead(); leading {
+		t.Fatal("expected the leadership to lapse")
+	}
+	b.campaign()
+	newToken, leading := b.lead()
+	if !leading || newToken <= token {
+		t.Fatalf("expected the second instance to lead with a newer token, got %d after %d", newToken, token)
+	}
+	other := &model.Job{UUID: "job_2", Name: "poll", TaskName: "poll", Status: model.Pending, RunAt: &now}
+	if a.claim(other, token) {
+		t.Fatal("expected the stale token to be fenced off")
+	}
+	if !b.claim(j, newToken) {
+		t.Fatal("expected the new leader to claim the job of the expired lease")
+	}
+}
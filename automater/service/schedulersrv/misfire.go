@@ -0,0 +1,104 @@
+package schedulersrv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// maxMissedRuns bounds the count of the missed runs of a schedule, eg: a job running every second down for a month.
+const maxMissedRuns = 1000
+
+// misfire applies the misfire policy of a recurring job, or of the pipeline of the job, overdue by more than the misfire
+// grace time. The missed runs are recorded as a MISFIRED transaction, it returns false if the job shouldn't run now.
+func (srv *schedulerService) misfire(j *model.Job, p *model.Pipeline) bool {
+	now := srv.time.Now()
+	if p != nil {
+		// The pipeline misfires on its first job, before the run started.
+		if !p.IsRecurring() || p.CatchUp > 0 || p.Status != model.Pending || p.RunAt == nil || !p.RunAt.Equal(*j.RunAt) {
+			return true
+		}
+		if now.Sub(*p.RunAt) <= srv.misfireGrace {
+			return true
+		}
+		o := p.PipelineOptions
+		policy, limit := o.MisfirePolicyAndLimit()
+		missed, ok := srv.missedRuns(j, *p.RunAt, now, o.RunOnInterval, o.Cron, o.Timezone, policy, limit)
+		if !ok {
+			return true
+		}
+		switch policy {
+		case model.MisfireSkip:
+			if _, err := srv.storage.RecyclePipeline(p.UUID, p); err != nil {
+				srv.logger.Errorf("could not recycle pipeline: %s", err)
+			}
+			return false
+		case model.MisfireRunAll:
+			p.CatchUp = missed
+			if err := srv.storage.UpdatePipeline(p.UUID, p); err != nil {
+				srv.logger.Errorf("could not update pipeline: %s", err)
+			}
+		}
+		return true
+	}
+
+	if !j.IsRecycleJob() || j.CatchUp > 0 || now.Sub(*j.RunAt) <= srv.misfireGrace {
+		return true
+	}
+	o := j.JobOptions
+	policy, limit := o.MisfirePolicyAndLimit()
+	missed, ok := srv.missedRuns(j, *j.RunAt, now, o.RunOnInterval, o.Cron, o.Timezone, policy, limit)
+	if !ok {
+		return true
+	}
+	switch policy {
+	case model.MisfireSkip:
+		if err := automater.RecycleRunAt(j); err != nil {
+			srv.logger.Errorf("could not get the next run of job: %s", err)
+			return false
+		}
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			srv.logger.Errorf("could not update job: %s", err)
+		}
+		return false
+	case model.MisfireRunAll:
+		j.CatchUp = missed
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			srv.logger.Errorf("could not update job: %s", err)
+		}
+	}
+	return true
+}
+
+// missedRuns counts the runs missed since runAt and records them as a MISFIRED transaction of the job.
+func (srv *schedulerService) missedRuns(
+	j *model.Job, runAt, now time.Time, runOnInterval, cronExpr, timezone, policy string, limit int) (int, bool) {
+
+	if policy != model.MisfireRunAll {
+		limit = maxMissedRuns
+	}
+	missed, err := automater.MissedRuns(runAt, now, runOnInterval, cronExpr, timezone, limit)
+	if err != nil {
+		srv.logger.Errorf("could not count the missed runs of job %s: %s", j.UUID, err)
+		return 0, false
+	}
+	var reason string
+	switch policy {
+	case model.MisfireSkip:
+		reason = fmt.Sprintf("missed %d runs since %s, skipped to the next run", missed, runAt.Format(time.RFC3339))
+	case model.MisfireRunAll:
+		reason = fmt.Sprintf("missed %d runs since %s, catching up", missed, runAt.Format(time.RFC3339))
+	default:
+		reason = fmt.Sprintf("missed %d runs since %s, running once", missed, runAt.Format(time.RFC3339))
+	}
+	srv.logger.Infof("%s, job uuid: %s", reason, j.UUID)
+	misfiredAt := srv.time.Now()
+	misfired := *j
+	misfired.MarkMisfired(&misfiredAt, reason)
+	if _, err := srv.storage.CreateTransaction(&misfired); err != nil {
+		srv.logger.Errorf("could not create transaction: %s", err)
+	}
+	return missed, true
+}
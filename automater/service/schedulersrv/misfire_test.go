@@ -0,0 +1,75 @@
+package schedulersrv
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+func newMisfiredJob(t *testing.T, storage *memory.Memory, policy string) *model.Job {
+	runAt := time.Now().Add(-10*time.Minute - 30*time.Second)
+	j := &model.Job{
+		UUID:     "job_" + policy,
+		Name:     "poll",
+		TaskName: "poll",
+		Status:   model.Pending,
+		RunAt:    &runAt,
+		JobOptions: &model.JobOptions{
+			EnableInterval: true,
+			RunOnInterval:  "1 min",
+			MisfirePolicy:  policy,
+			MisfireLimit:   5,
+		},
+	}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestScheduler_Misfire(t *testing.T) {
+	storage := memory.New()
+	srv := New(nil, storage, nil, wakeup.New(), intime.New(), time.Minute, logrus.New())
+
+	skipped := newMisfiredJob(t, storage, model.MisfireSkip)
+	if srv.misfire(skipped, nil) {
+		t.Fatal("expected the skip policy not to run the job")
+	}
+	if !skipped.RunAt.After(time.Now()) {
+		t.Fatalf("expected the job to wait for its next run, got %s", skipped.RunAt)
+	}
+	transactions, _ := storage.GetTransactionsByJob(skipped.UUID)
+	if len(transactions) != 1 || transactions[0].Status != model.Misfired ||
+		!strings.HasPrefix(transactions[0].FailureReason, "missed 11 runs") {
+		t.Fatalf("expected a misfired transaction for 11 runs, got %+v", transactions)
+	}
+
+	catchUp := newMisfiredJob(t, storage, model.MisfireRunAll)
+	if !srv.misfire(catchUp, nil) {
+		t.Fatal("expected the run_all policy to run the job")
+	}
+	if catchUp.CatchUp != 5 {
+		t.Fatalf("expected 5 runs to catch up, got %d", catchUp.CatchUp)
+	}
+	// The catch up runs aren't misfires.
+	if !srv.misfire(catchUp, nil) {
+		t.Fatal("expected the catch up run to run")
+	}
+	transactions, _ = storage.GetTransactionsByJob(catchUp.UUID)
+	if len(transactions) != 1 {
+		t.Fatalf("expected a single misfired transaction, got %d", len(transactions))
+	}
+
+	onTime := newMisfiredJob(t, storage, model.MisfireSkip)
+	now := time.Now()
+	onTime.RunAt = &now
+	if !srv.misfire(onTime, nil) {
+		t.Fatal("expected a job within the grace time to run")
+	}
+}
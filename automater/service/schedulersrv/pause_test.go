@@ -0,0 +1,63 @@
+package schedulersrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/jobqueue"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduler_PausedQueueWaitsInStorage(t *testing.T) {
+	storage := memory.New()
+	jobQueue := jobqueue.NewMemoryQueue(10, "text")
+	srv := New(jobQueue, storage, nil, wakeup.New(), intime.New(), time.Minute, logrus.New())
+
+	now := time.Now()
+	if _, err := storage.UpdatePause(func(p *model.Pause) {
+		p.PauseQueue("installs", &now)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	j := &model.Job{UUID: "job_1", Name: "install", TaskName: "install", Queue: "installs", Status: model.Pending, CreatedAt: &now}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobQueue.Push(j); err != nil {
+		t.Fatal(err)
+	}
+	srv.dispatch()
+	if queued := jobQueue.Pop(); queued != nil {
+		t.Fatalf("expected the job of the paused queue to leave the job queue, got %v", queued)
+	}
+	// The job waits in the storage for its queue to be resumed.
+	due, err := storage.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].UUID != j.UUID {
+		t.Fatalf("expected the job of the paused queue to be due, got %v", due)
+	}
+
+	p, err := storage.UpdatePause(func(p *model.Pause) {
+		p.ResumeQueue("installs", &now)
+		p.PauseQueue("", &now)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsPaused("installs") || !p.IsPaused("") || p.IsQueuePaused("installs") {
+		t.Fatal("expected every queue to be paused globally")
+	}
+	if srv.schedule() {
+		t.Fatal("expected a paused scheduler not to be busy")
+	}
+	stored, _ := storage.GetJob(j.UUID)
+	if stored.Status != model.Pending {
+		t.Fatalf("expected the due job to stay pending, got %s", stored.Status)
+	}
+}
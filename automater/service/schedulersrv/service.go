@@ -5,19 +5,43 @@ import (
 	"fmt"
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
 	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
 	"github.com/sirupsen/logrus"
+	"sort"
 	"time"
 )
 
 var _ automater.Scheduler = &schedulerService{}
 
+// skippedReason is the failure reason of the runs skipped by the forbid concurrency policy.
+const skippedReason = "skipped: the previous run is still in progress"
+
+// skippedElsewhereReason is the reason of the runs of a job with the replace policy skipped because another instance
+// holds the previous run.
+const skippedElsewhereReason = "skipped: the previous run is in progress on another instance"
+
+const (
+	// timerSlack wakes the scheduler up a bit after the due time, the redis index rounds the due times to the millisecond.
+	timerSlack = 5 * time.Millisecond
+	// busyRetry is the delay before scheduling again the due jobs left behind by a busy worker pool.
+	busyRetry = time.Second
+	// maxSleep bounds the sleep of the scheduler, the storage polling interval is usually shorter.
+	maxSleep = time.Hour
+)
+
 type schedulerService struct {
 	jobQueue    automater.JobQueue
 	storage     automater.Storage
 	workService automater.WorkService
+	wakeup      *wakeup.Wakeup
 	time        intime.Time
-	logger      *logrus.Logger
+	// A recurring job overdue by more than the misfire grace time missed some runs.
+	misfireGrace time.Duration
+	// The scheduler leadership of this instance, nil when the scheduler leads on its own.
+	leader *leadership
+	logger *logrus.Logger
 }
 
 // New creates a new scheduler server.
@@ -25,110 +49,331 @@ func New(
 	jobQueue automater.JobQueue,
 	storage automater.Storage,
 	workService automater.WorkService,
+	wakeup *wakeup.Wakeup,
 	time intime.Time,
+	misfireGrace time.Duration,
 	logger *logrus.Logger) *schedulerService {
 
 	return &schedulerService{
-		jobQueue:    jobQueue,
-		storage:     storage,
-		workService: workService,
-		time:        time,
-		logger:      logger,
+		jobQueue:     jobQueue,
+		storage:      storage,
+		workService:  workService,
+		wakeup:       wakeup,
+		time:         time,
+		misfireGrace: misfireGrace,
+		logger:       logger,
 	}
 }
 
-// Dispatch listens to the job queue for messages, consumes them and
-// dispatches the work items to the worker pool for execution.
+// Dispatch listens to the job queue for messages, consumes them and dispatches the work items to the worker pool for
+// execution. The job queue is drained whenever some work gets pushed to it, and polled in the given interval.
 func (srv *schedulerService) Dispatch(ctx context.Context, duration time.Duration) {
 	ticker := time.NewTicker(duration)
 	go func() {
 		for {
-			srv.logger.Info("running loop dispatch job")
 			select {
 			case <-ctx.Done():
 				ticker.Stop()
 				srv.logger.Info("exiting...")
 				return
 			case <-ticker.C:
-				j := srv.jobQueue.Pop()
-				if j == nil {
-					srv.logger.Info("dispatch no job to run")
-					continue
-				}
-				srv.logger.Info("dispatch a new job uuid:", j.UUID)
-				w := srv.workService.CreateWork(j)
-				// Blocks until worker pool backlog has some space.
-				srv.workService.Dispatch(w)
-				message := fmt.Sprintf("job with UUID: %s", j.UUID)
-				if j.BelongsToPipeline() {
-					message = fmt.Sprintf("pipeline with UUID: %s", j.PipelineID)
-				}
-				srv.logger.Infof("sent work for %s to worker pool", message)
+			case <-srv.wakeup.Queued():
 			}
+			srv.dispatch()
 		}
 	}()
 }
 
-// Schedule polls the storage in given interval and schedules due jobs for execution.
+// dispatch sends the work of the queued jobs to the worker pool, until the job queue is empty or a worker pool is busy.
+// The jobs of the paused queues are handed over to the scheduler, they're scheduled once their queue is resumed.
+func (srv *schedulerService) dispatch() {
+	pause, err := srv.storage.GetPause()
+	if err != nil {
+		srv.logger.Errorf("could not get the paused state from storage: %s", err)
+		return
+	}
+	if pause.Global {
+		return
+	}
+	for {
+		j := srv.jobQueue.Pop()
+		if j == nil {
+			return
+		}
+		if pause.IsPaused(j.Queue) {
+			srv.park(j)
+			continue
+		}
+		srv.logger.Info("dispatch a new job uuid:", j.UUID)
+		if !srv.admit(j) {
+			// Drop the duplicate message.
+			if err := srv.jobQueue.Ack(j); err != nil {
+				srv.logger.Errorf("could not ack job: %s", err)
+			}
+			continue
+		}
+		w := srv.workService.CreateWork(j)
+		w.Ack = func() error {
+			return srv.jobQueue.Ack(j)
+		}
+		if !srv.workService.TryDispatch(w) {
+			// Don't let a busy queue hold up the others, hand the job back for a later attempt.
+			srv.logger.Infof("worker pool of queue %s is busy, requeueing job uuid: %s", j.Queue, j.UUID)
+			if err := srv.jobQueue.Push(j); err != nil {
+				srv.logger.Errorf("could not requeue job: %s", err)
+				return
+			}
+			if err := srv.jobQueue.Ack(j); err != nil {
+				srv.logger.Errorf("could not ack job: %s", err)
+			}
+			return
+		}
+		message := fmt.Sprintf("job with UUID: %s", j.UUID)
+		if j.BelongsToPipeline() {
+			message = fmt.Sprintf("pipeline with UUID: %s", j.PipelineID)
+		}
+		srv.logger.Infof("sent work for %s to worker pool", message)
+	}
+}
+
+// Schedule schedules the due jobs for execution, it sleeps until the earliest run_at of the pending jobs and wakes
+// up earlier when the services notify some work got due before. The storage is polled in the given interval in case
+// some work got due without a notification, and the overdue jobs are picked up on startup.
 func (srv *schedulerService) Schedule(ctx context.Context, duration time.Duration) {
 	ticker := time.NewTicker(duration)
 	go func() {
+		run := true
+		var next *time.Time
 		for {
+			if run {
+				next = srv.schedulePass()
+			}
+			timer := time.NewTimer(srv.untilNext(next))
 			select {
 			case <-ctx.Done():
+				timer.Stop()
 				ticker.Stop()
 				srv.logger.Info("exiting schedule...")
 				return
 			case <-ticker.C:
-				dueJobs, err := srv.storage.GetDueJobs()
-				srv.logger.Infoln("schedule loop job count:", len(dueJobs))
-				if err != nil {
-					srv.logger.Errorf("could not get due jobs from storage: %s", err)
+				run = true
+			case <-timer.C:
+				run = true
+			case <-srv.wakeup.Changed():
+				// Only plan the wake up again, the new due time may still be ahead.
+				run = false
+			}
+			timer.Stop()
+		}
+	}()
+}
+
+// schedulePass schedules the due jobs and returns the earliest run_at of the pending jobs, if any.
+func (srv *schedulerService) schedulePass() *time.Time {
+	now := srv.time.Now()
+	// The notified work got stored before the notification, so the pass below picks it up.
+	srv.wakeup.PopDue(now)
+	var next *time.Time
+	if srv.schedule() {
+		retryAt := now.Add(busyRetry)
+		next = &retryAt
+	}
+	runAt, err := srv.storage.GetNextRunAt(now)
+	if err != nil {
+		srv.logger.Errorf("could not get the next run from storage: %s", err)
+		return next
+	}
+	if runAt != nil && (next == nil || runAt.Before(*next)) {
+		next = runAt
+	}
+	return next
+}
+
+// untilNext returns how long to sleep until the next due time, the earliest of the given one and the notified ones.
+func (srv *schedulerService) untilNext(next *time.Time) time.Duration {
+	if notified, ok := srv.wakeup.Next(); ok && (next == nil || notified.Before(*next)) {
+		next = &notified
+	}
+	if next == nil {
+		// Nothing is due, the ticker polls the storage in the meantime.
+		return maxSleep
+	}
+	wait := next.Sub(srv.time.Now()) + timerSlack
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxSleep {
+		return maxSleep
+	}
+	return wait
+}
+
+// schedule dispatches the due jobs to the worker pool, it returns true if some due jobs were left behind by a busy
+// worker pool. The due jobs of the paused queues stay due until resumed.
+func (srv *schedulerService) schedule() bool {
+	pause, err := srv.storage.GetPause()
+	if err != nil {
+		srv.logger.Errorf("could not get the paused state from storage: %s", err)
+		return true
+	}
+	if pause.Global {
+		srv.logger.Info("scheduler is paused")
+		return false
+	}
+	token, leading := srv.lead()
+	if !leading {
+		// The leader schedules the due jobs.
+		return false
+	}
+	dueJobs, err := srv.storage.GetDueJobs()
+	srv.logger.Infoln("schedule loop job count:", len(dueJobs))
+	if err != nil {
+		srv.logger.Errorf("could not get due jobs from storage: %s", err)
+		return true
+	}
+	blackouts, err := srv.storage.GetBlackouts()
+	if err != nil {
+		// Don't touch the devices if the blackouts can't be checked.
+		srv.logger.Errorf("could not get blackouts from storage: %s", err)
+		return true
+	}
+	busy := false
+	// Urgent work first, the storage orders the jobs of the same priority by run_at.
+	sort.SliceStable(dueJobs, func(i, j int) bool {
+		return dueJobs[i].Priority > dueJobs[j].Priority
+	})
+	for _, j := range dueJobs {
+		if pause.IsPaused(j.Queue) {
+			continue
+		}
+		var p *model.Pipeline
+		if !j.BelongsToPipeline() {
+			if j.Disable {
+				srv.logger.Infoln("schedule JOB Is Disable name:", j.Name)
+				continue
+			} else {
+				srv.logger.Infoln("schedule JOB IS Not Disable", j.Name)
+			}
+		} else {
+			p, _ = srv.storage.GetPipeline(j.PipelineID)
+			if p.IsDisabled() { // reset the pipeline
+				srv.storage.RecyclePipeline(j.PipelineID, p) // reset the pipeline
+				continue
+			}
+			if p.CancelOnFailure() { // quite pipeline if a job has failed
+				if p.Status == model.Failed {
+					srv.storage.RecyclePipeline(j.PipelineID, p) // reset the pipeline
 					continue
 				}
-				for _, j := range dueJobs {
-					if !j.BelongsToPipeline() {
-						if j.Disable {
-							srv.logger.Infoln("schedule JOB Is Disable name:", j.Name)
-							continue
-						} else {
-							srv.logger.Infoln("schedule JOB IS Not Disable", j.Name)
-						}
-					} else {
-						p, _ := srv.storage.GetPipeline(j.PipelineID)
-						if p.IsDisabled() { // reset the pipeline
-							srv.storage.RecyclePipeline(j.PipelineID, p) // reset the pipeline
-							continue
-						}
-						if p.CancelOnFailure() { // quite pipeline if a job has failed
-							if p.Status == model.Failed {
-								srv.storage.RecyclePipeline(j.PipelineID, p) // reset the pipeline
-								continue
-							}
-						}
-						for job := j; job.HasNext(); job = job.Next {
-							job.Next, err = srv.storage.GetJob(job.NextJobID)
-							if err != nil {
-								srv.logger.Errorf("could not get piped due job from storage: %s", err)
-								continue
-							}
-						}
-					}
-					w := srv.workService.CreateWork(j)
-					// Blocks until worker pool backlog has some space.
-					srv.workService.Dispatch(w)
-					scheduledAt := srv.time.Now()
-					j.MarkScheduled(&scheduledAt)
-					if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
-						srv.logger.Errorf("could not update job: %s", err)
-					}
-					message := fmt.Sprintf("job with UUID: %s", j.UUID)
-					if j.BelongsToPipeline() {
-						message = fmt.Sprintf("pipeline with UUID: %s", j.PipelineID)
-					}
-					srv.logger.Infof("scheduled work for %s to worker pool", message)
+			}
+			for job := j; job.HasNext(); job = job.Next {
+				job.Next, err = srv.storage.GetJob(job.NextJobID)
+				if err != nil {
+					srv.logger.Errorf("could not get piped due job from storage: %s", err)
+					continue
 				}
 			}
 		}
-	}()
+		if srv.blackout(j, p, blackouts) {
+			continue
+		}
+		if !srv.misfire(j, p) {
+			continue
+		}
+		if !srv.admit(j) {
+			srv.skip(j)
+			continue
+		}
+		if !srv.claim(j, token) {
+			continue
+		}
+		w := srv.workService.CreateWork(j)
+		if !srv.workService.TryDispatch(w) {
+			// Don't let a busy queue hold up the others, the job stays due for the next poll.
+			srv.logger.Infof("worker pool of queue %s is busy, skipping job uuid: %s", j.Queue, j.UUID)
+			busy = true
+			continue
+		}
+		scheduledAt := srv.time.Now()
+		j.MarkScheduled(&scheduledAt)
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			srv.logger.Errorf("could not update job: %s", err)
+		}
+		message := fmt.Sprintf("job with UUID: %s", j.UUID)
+		if j.BelongsToPipeline() {
+			message = fmt.Sprintf("pipeline with UUID: %s", j.PipelineID)
+		}
+		srv.logger.Infof("scheduled work for %s to worker pool", message)
+	}
+	return busy
+}
+
+// park stores a queued job of a paused queue as due and acknowledges it, so it waits in the storage instead of going
+// round the job queue until its queue is resumed. A job that got deleted or isn't active anymore is dropped.
+func (srv *schedulerService) park(queued *model.Job) {
+	j, err := srv.storage.GetJob(queued.UUID)
+	if _, deleted := err.(*apperrors.NotFoundErr); err != nil && !deleted {
+		srv.logger.Errorf("could not get queued job of paused queue: %s", err)
+		return
+	}
+	if err == nil && j.IsActive() {
+		now := srv.time.Now()
+		if j.RunAt == nil || j.RunAt.After(now) {
+			j.Postpone(&now)
+		}
+		j.Status = model.Pending
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			srv.logger.Errorf("could not park job of paused queue: %s", err)
+			return
+		}
+		srv.logger.Infof("queue %s is paused, job uuid: %s waits for its resume", j.Queue, j.UUID)
+	}
+	if err := srv.jobQueue.Ack(queued); err != nil {
+		srv.logger.Errorf("could not ack job: %s", err)
+	}
+}
+
+// admit applies the concurrency policy of the job, the run is skipped while the previous run of a job with the forbid
+// policy is still in progress. The replace policy is applied by the worker running the job, a run held by another
+// instance can't be stopped from here so the new run is skipped then.
+func (srv *schedulerService) admit(j *model.Job) bool {
+	reason := skippedReason
+	switch j.ConcurrencyPolicy() {
+	case model.ConcurrencyForbid:
+		if !srv.workService.IsRunning(j) {
+			return true
+		}
+	case model.ConcurrencyReplace:
+		if !srv.workService.IsRunningElsewhere(j) {
+			return true
+		}
+		reason = skippedElsewhereReason
+	default:
+		return true
+	}
+	srv.logger.Infof("%s, job uuid: %s", reason, j.UUID)
+	skippedAt := srv.time.Now()
+	skipped := *j
+	skipped.MarkSkipped(&skippedAt, reason)
+	if _, err := srv.storage.CreateTransaction(&skipped); err != nil {
+		srv.logger.Errorf("could not create transaction: %s", err)
+	}
+	return false
+}
+
+// skip moves a skipped due job out of the way, a recurring job waits for its next run and the other jobs are
+// marked as skipped until the run in progress stores its outcome.
+func (srv *schedulerService) skip(j *model.Job) {
+	if j.IsRecycleJob() {
+		if err := automater.RecycleRunAt(j); err != nil {
+			srv.logger.Errorf("could not get the next run of job: %s", err)
+			return
+		}
+	} else {
+		skippedAt := srv.time.Now()
+		j.MarkSkipped(&skippedAt, skippedReason)
+	}
+	if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+		srv.logger.Errorf("could not update job: %s", err)
+	}
 }
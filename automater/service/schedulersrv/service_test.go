@@ -0,0 +1,42 @@
+package schedulersrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduler_NextWakeup(t *testing.T) {
+	storage := memory.New()
+	notifier := wakeup.New()
+	srv := New(nil, storage, nil, notifier, intime.New(), time.Minute, logrus.New())
+
+	if wait := srv.untilNext(srv.schedulePass()); wait != maxSleep {
+		t.Fatalf("expected an idle scheduler to sleep, got %s", wait)
+	}
+
+	runAt := time.Now().Add(10 * time.Minute)
+	j := &model.Job{UUID: "job_1", Name: "poll", TaskName: "poll", Status: model.Pending, RunAt: &runAt}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	next := srv.schedulePass()
+	if next == nil || !next.Equal(runAt) {
+		t.Fatalf("expected the next wake up at %s, got %v", runAt, next)
+	}
+	if wait := srv.untilNext(next); wait < 9*time.Minute || wait > 10*time.Minute+timerSlack {
+		t.Fatalf("expected to sleep until the run of the job, got %s", wait)
+	}
+
+	// A job created in the meantime with an earlier run wakes the scheduler up.
+	notifier.NotifyDue(time.Now().Add(time.Minute))
+	<-notifier.Changed()
+	if wait := srv.untilNext(next); wait > time.Minute+timerSlack {
+		t.Fatalf("expected to wake up for the notified run, got %s", wait)
+	}
+}
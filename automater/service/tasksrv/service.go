@@ -20,6 +20,11 @@ func (srv *taskService) Register(name string, taskFunc taskRepo.TaskFunc) {
 	srv.taskRepo.Register(name, taskFunc)
 }
 
+// RegisterWithContext registers a new context aware tasks in the tasks database.
+func (srv *taskService) RegisterWithContext(name string, taskFunc taskRepo.ContextTaskFunc) {
+	srv.taskRepo.RegisterWithContext(name, taskFunc)
+}
+
 // GetTaskRepository returns the tasks database.
 func (srv *taskService) GetTaskRepository() *taskRepo.TaskRepository {
 	return srv.taskRepo
@@ -1,24 +1,97 @@
 package taskrepo
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
 )
 
-// TaskFunc is the type of the tasks callback.
+// TaskFunc is the type of the legacy tasks callback, it gets the task params and the previous job results if any.
 type TaskFunc func(...interface{}) (interface{}, error)
 
+// ContextTaskFunc is the type of the context aware tasks callback. The context is done when the job times out, is
+// cancelled or replaced, the task should return as soon as possible then.
+type ContextTaskFunc func(ctx context.Context, in TaskInput) (interface{}, error)
+
+// TaskInput is the input of a context aware task.
+type TaskInput struct {
+	// Params are the task params of the job.
+	Params interface{}
+	// PreviousResults are the results metadata of the previous job of the pipeline, when the job uses them.
+	PreviousResults interface{}
+	JobID           string
+	PipelineID      string
+	// Attempt is the number of the current attempt of the run, starting from 1.
+	Attempt int
+	// Logger logs with the job, pipeline and task fields.
+	Logger *logrus.Entry
+}
+
+// DecodeParams uses https://github.com/mitchellh/mapstructure to decode the task params to a pointer of map or struct.
+func (in TaskInput) DecodeParams(params interface{}) error {
+	return mapstructure.Decode(in.Params, params)
+}
+
+// DecodePreviousResults uses https://github.com/mitchellh/mapstructure to decode the previous job results to a
+// pointer of map or struct, it's a no-op without previous results.
+func (in TaskInput) DecodePreviousResults(results interface{}) error {
+	if in.PreviousResults == nil {
+		return nil
+	}
+	return mapstructure.Decode(in.PreviousResults, results)
+}
+
+// WithContext adapts a legacy task to the context aware signature. The legacy task doesn't see the context, it keeps
+// running in the background when the context is done.
+func WithContext(taskFunc TaskFunc) ContextTaskFunc {
+	return func(ctx context.Context, in TaskInput) (interface{}, error) {
+		params := []interface{}{
+			in.Params,
+		}
+		if in.PreviousResults != nil {
+			params = append(params, in.PreviousResults)
+		}
+		return taskFunc(params...)
+	}
+}
+
 // TaskRepository is the in memory tasks database.
-type TaskRepository map[string]TaskFunc
+type TaskRepository map[string]ContextTaskFunc
 
 // New initializes and returns a new TaskRepository instance.
 func New() *TaskRepository {
-	repo := make(map[string]TaskFunc)
+	repo := make(map[string]ContextTaskFunc)
 	taskRepo := TaskRepository(repo)
 	return &taskRepo
 }
 
+// withoutContext adapts a context aware task to the legacy signature, it runs with a background context.
+func withoutContext(taskFunc ContextTaskFunc) TaskFunc {
+	return func(params ...interface{}) (interface{}, error) {
+		var in TaskInput
+		if len(params) > 0 {
+			in.Params = params[0]
+		}
+		if len(params) > 1 {
+			in.PreviousResults = params[1]
+		}
+		return taskFunc(context.Background(), in)
+	}
+}
+
 // GetTaskFunc returns the TaskFunc for a specified name if that exists in the tasks database.
 func (repo TaskRepository) GetTaskFunc(name string) (TaskFunc, error) {
+	task, err := repo.GetContextTaskFunc(name)
+	if err != nil {
+		return nil, err
+	}
+	return withoutContext(task), nil
+}
+
+// GetContextTaskFunc returns the ContextTaskFunc for a specified name if that exists in the tasks database.
+func (repo TaskRepository) GetContextTaskFunc(name string) (ContextTaskFunc, error) {
 	task, ok := repo[name]
 	if !ok {
 		return nil, fmt.Errorf("tasks with name: %s is not registered", name)
@@ -35,7 +108,12 @@ func (repo TaskRepository) GetTaskNames() []string {
 	return names
 }
 
-// Register adds a new tasks in the database.
+// Register adds a new legacy tasks in the database.
 func (repo TaskRepository) Register(name string, taskFunc TaskFunc) {
+	repo[name] = WithContext(taskFunc)
+}
+
+// RegisterWithContext adds a new context aware tasks in the database.
+func (repo TaskRepository) RegisterWithContext(name string, taskFunc ContextTaskFunc) {
 	repo[name] = taskFunc
 }
@@ -0,0 +1,36 @@
+package taskrepo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTaskRepository_GetTaskFunc(t *testing.T) {
+	repo := New()
+	repo.Register("legacy", func(params ...interface{}) (interface{}, error) {
+		return params, nil
+	})
+	repo.RegisterWithContext("aware", func(ctx context.Context, in TaskInput) (interface{}, error) {
+		return []interface{}{in.Params, in.PreviousResults}, nil
+	})
+
+	for _, name := range []string{"legacy", "aware"} {
+		taskFunc, err := repo.GetTaskFunc(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := taskFunc("params", "previous")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := result.([]interface{}); len(got) != 2 || got[0] != "params" || got[1] != "previous" {
+			t.Fatalf("expected task %s to get its params and previous results, got %v", name, got)
+		}
+		if _, err := repo.GetContextTaskFunc(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.GetTaskFunc("missing"); err == nil {
+		t.Fatal("expected an unregistered task to be reported")
+	}
+}
@@ -0,0 +1,340 @@
+package worksrv
+
+import (
+	"context"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/automater/service/worksrv/work"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/expr"
+)
+
+const (
+	// upstreamFailedReason is the reason of the steps of a graph pipeline skipped because the pipeline failed first.
+	upstreamFailedReason = "skipped, the pipeline failed before the steps it waits for ended"
+	// notTakenReason is the reason of the steps skipped because a step they wait for didn't end as they require,
+	// eg: the on_failure branch of a step which completed.
+	notTakenReason = "skipped, a step it waits for did not end as required"
+	// conditionReason is the reason of the steps skipped because their condition doesn't hold.
+	conditionReason = "skipped, its condition does not hold"
+)
+
+// execStep executes a step of a graph pipeline, the steps it waits for ended already. The steps are dispatched
+// one by one, so the independent steps run in parallel on the worker pool.
+func (srv *workService) execStep(ctx context.Context, w work.Work) error {
+	if err := srv.startPipeline(w.Job.PipelineID); err != nil {
+		return err
+	}
+	run, err := srv.rootConditionHolds(w.Job)
+	if err != nil {
+		return err
+	}
+	if !run {
+		if _, err := srv.storage.UpdateJob(w.Job.UUID, w.Job); err != nil {
+			return err
+		}
+		return srv.advance(w.Job)
+	}
+	var previousResults interface{}
+	if w.Job.UsePreviousResults {
+		results, err := srv.upstreamResults(w.Job)
+		if err != nil {
+			return err
+		}
+		if results != nil {
+			previousResults = results
+		}
+	}
+
+	jobResult, err := srv.attemptInPlace(ctx, w.Job, w.TimeoutUnit, previousResults)
+	if err != nil {
+		return err
+	}
+	if srv.runs.stopReason(ctx) != "" && !srv.runs.cancelled(ctx) {
+		// The run that stopped this one owns the step now.
+		return srv.storage.CreateJobResult(&jobResult)
+	}
+	if _, err := srv.storage.UpdateJob(w.Job.UUID, w.Job); err != nil {
+		return err
+	}
+	// The result is stored before the next steps get due, they may use it.
+	if err := srv.storage.CreateJobResult(&jobResult); err != nil {
+		return err
+	}
+	if srv.runs.cancelled(ctx) {
+		// The pipeline got cancelled along, its next steps don't run.
+		return nil
+	}
+	return srv.advance(w.Job)
+}
+
+// startPipeline marks the pipeline as started when its first step starts.
+func (srv *workService) startPipeline(pipelineID string) error {
+	srv.steps.Lock()
+	defer srv.steps.Unlock()
+	p, err := srv.storage.GetPipeline(pipelineID)
+	if err != nil {
+		return err
+	}
+	if p.Status != model.Pending {
+		return nil
+	}
+	startedAt := srv.time.Now()
+	p.MarkStarted(&startedAt)
+	return srv.storage.UpdatePipeline(p.UUID, p)
+}
+
+// rootConditionHolds evaluates the condition of a step waiting for no other step, advance evaluates the condition of
+// the other steps before they get due. A step whose condition doesn't hold is skipped, and a broken condition fails it.
+func (srv *workService) rootConditionHolds(j *model.Job) (bool, error) {
+	if j.When == "" {
+		return true, nil
+	}
+	p, jobs, steps, err := srv.pipelineSteps(j)
+	if err != nil {
+		return false, err
+	}
+	if len(model.Upstreams(jobs)[j.Name]) > 0 {
+		return true, nil
+	}
+	now := srv.time.Now()
+	run, err := srv.conditionHolds(j, p, steps)
+	if err != nil {
+		// Don't guess what a broken condition meant.
+		j.MarkFailed(&now, err.Error())
+		if _, err := srv.storage.CreateTransaction(j); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	if !run {
+		j.MarkSkipped(&now, conditionReason)
+	}
+	return run, nil
+}
+
+// upstreamResults returns the results of the steps the job waits for by step name, or nil if it waits for none.
+func (srv *workService) upstreamResults(j *model.Job) (map[string]interface{}, error) {
+	jobs, err := srv.storage.GetJobsByPipelineID(j.PipelineID)
+	if err != nil {
+		return nil, err
+	}
+	upstreams := model.Upstreams(jobs)[j.Name]
+	if len(upstreams) == 0 {
+		return nil, nil
+	}
+	results := make(map[string]interface{}, len(upstreams))
+	for _, u := range upstreams {
+		for _, step := range jobs {
+			if step.Name != u.Step {
+				continue
+			}
+			result, err := srv.jobResult(step)
+			if err != nil {
+				return nil, err
+			}
+			results[u.Step] = result.Metadata
+		}
+	}
+	return results, nil
+}
+
+// jobResult fetches the result of a job, it's empty if the job has none.
+func (srv *workService) jobResult(j *model.Job) (*model.JobResult, error) {
+	result, err := srv.storage.GetJobResult(j.UUID)
+	if err != nil {
+		if _, ok := err.(*apperrors.NotFoundErr); ok {
+			return &model.JobResult{JobID: j.UUID}, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// lookupValues returns the values the step conditions and the task params templates look up: the status, result and
+// error of every step of the pipeline by name, the pipeline variables and the run of the job.
+func (srv *workService) lookupValues(j *model.Job, p *model.Pipeline, steps map[string]*model.Job) (map[string]interface{}, error) {
+	stepValues := make(map[string]interface{}, len(steps))
+	for name, step := range steps {
+		value := map[string]interface{}{
+			model.StepStatus: step.Status.String(),
+			model.StepResult: nil,
+			model.StepError:  step.FailureReason,
+		}
+		if step.Status == model.Completed || step.Status == model.Failed {
+			result, err := srv.jobResult(step)
+			if err != nil {
+				return nil, err
+			}
+			value[model.StepResult] = result.Metadata
+		}
+		stepValues[name] = value
+	}
+	run := map[string]interface{}{
+		model.RunJobID:      j.UUID,
+		model.RunPipelineID: j.PipelineID,
+		model.RunAttempt:    j.Attempt,
+	}
+	startedAt := j.StartedAt
+	if p != nil && p.StartedAt != nil {
+		startedAt = p.StartedAt
+	}
+	if startedAt != nil {
+		run[model.RunStartedAt] = startedAt.Format(time.RFC3339)
+	}
+	values := map[string]interface{}{
+		model.ScopeSteps: stepValues,
+		model.ScopeRun:   run,
+	}
+	if p != nil {
+		if vars := p.Variables(); vars != nil {
+			values[model.ScopeVars] = vars
+		}
+	}
+	return values, nil
+}
+
+// pipelineSteps fetches the pipeline of the job and its steps by name, the pipeline copies of the steps may be behind.
+func (srv *workService) pipelineSteps(j *model.Job) (*model.Pipeline, []*model.Job, map[string]*model.Job, error) {
+	p, err := srv.storage.GetPipeline(j.PipelineID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	jobs := make([]*model.Job, 0, len(p.Jobs))
+	steps := make(map[string]*model.Job, len(p.Jobs))
+	for _, step := range p.Jobs {
+		job, err := srv.storage.GetJob(step.UUID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		jobs = append(jobs, job)
+		steps[job.Name] = job
+	}
+	return p, jobs, steps, nil
+}
+
+// renderParams returns the task params of the job with their templates resolved.
+func (srv *workService) renderParams(j *model.Job) (map[string]interface{}, error) {
+	if !expr.HasTemplates(j.TaskParams) {
+		return j.TaskParams, nil
+	}
+	var p *model.Pipeline
+	var steps map[string]*model.Job
+	if j.BelongsToPipeline() {
+		var err error
+		if p, _, steps, err = srv.pipelineSteps(j); err != nil {
+			return nil, err
+		}
+	}
+	values, err := srv.lookupValues(j, p, steps)
+	if err != nil {
+		return nil, err
+	}
+	params, err := expr.Render(j.TaskParams, values)
+	if err != nil {
+		return nil, err
+	}
+	return params.(map[string]interface{}), nil
+}
+
+// conditionHolds evaluates the condition of a ready step, a step without one always runs.
+func (srv *workService) conditionHolds(step *model.Job, p *model.Pipeline, steps map[string]*model.Job) (bool, error) {
+	if step.When == "" {
+		return true, nil
+	}
+	condition, err := expr.Parse(step.When)
+	if err != nil {
+		return false, err
+	}
+	values, err := srv.lookupValues(step, p, steps)
+	if err != nil {
+		return false, err
+	}
+	return condition.Eval(values)
+}
+
+// advance moves a graph pipeline on once a step ended. The waiting steps get due as soon as all the steps they wait
+// for ended as expected and their condition holds, otherwise they're skipped. A failed step fails the pipeline, unless
+// it branches on failure, and skips the steps still waiting. The pipeline is done once none of its steps is due or
+// running, a recurring pipeline is recycled then.
+func (srv *workService) advance(j *model.Job) error {
+	srv.steps.Lock()
+	defer srv.steps.Unlock()
+	p, jobs, steps, err := srv.pipelineSteps(j)
+	if err != nil {
+		return err
+	}
+	if p.Status == model.Cancelled {
+		return nil
+	}
+	p.Jobs = jobs
+	upstreams := model.Upstreams(jobs)
+
+	if j.Status == model.Failed && !j.HandlesFailure() && p.Status != model.Failed {
+		p.MarkFailed(j.CompletedAt)
+	}
+	now := srv.time.Now()
+	// A skipped step may block the steps waiting for it in turn.
+	for changed := true; changed; {
+		changed = false
+		for _, step := range jobs {
+			if step.Status != model.Pending || step.IsScheduled() {
+				continue
+			}
+			ready, blocked := step.Ready(upstreams[step.Name], steps)
+			switch {
+			case p.Status == model.Failed:
+				step.MarkSkipped(&now, upstreamFailedReason)
+			case blocked:
+				step.MarkSkipped(&now, notTakenReason)
+			case !ready:
+				continue
+			default:
+				run, err := srv.conditionHolds(step, p, steps)
+				if err != nil {
+					// Don't guess what a broken condition meant.
+					step.MarkFailed(&now, err.Error())
+					if _, err := srv.storage.CreateTransaction(step); err != nil {
+						return err
+					}
+					if !step.HandlesFailure() {
+						p.MarkFailed(&now)
+					}
+				} else if !run {
+					step.MarkSkipped(&now, conditionReason)
+				} else {
+					runAt := now
+					step.RunAt = &runAt
+				}
+			}
+			changed = true
+			if _, err := srv.storage.UpdateJob(step.UUID, step); err != nil {
+				return err
+			}
+			if step.Status == model.Pending {
+				srv.notifier.NotifyDue(*step.RunAt)
+			}
+		}
+	}
+
+	for _, step := range jobs {
+		if step.IsActive() && step.IsScheduled() {
+			return srv.storage.UpdatePipeline(p.UUID, p)
+		}
+	}
+	if p.Status != model.Failed {
+		p.MarkCompleted(j.CompletedAt)
+	}
+	if !p.IsRecurring() {
+		return srv.storage.UpdatePipeline(p.UUID, p)
+	}
+	recycled, err := srv.storage.RecyclePipeline(p.UUID, p)
+	if err != nil {
+		return err
+	}
+	if recycled.RunAt != nil {
+		srv.notifier.NotifyDue(*recycled.RunAt)
+	}
+	return nil
+}
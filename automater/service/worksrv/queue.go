@@ -0,0 +1,107 @@
+package worksrv
+
+import (
+	"sync"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/automater/service/worksrv/work"
+)
+
+// workerPool is a set of workers serving the backlog of a queue.
+type workerPool struct {
+	name    string
+	workers int
+	queue   *workQueue
+}
+
+func newWorkerPool(name string, workers, queueCapacity int) *workerPool {
+	return &workerPool{
+		name:    name,
+		workers: workers,
+		queue:   newWorkQueue(queueCapacity),
+	}
+}
+
+// workQueue is the bounded backlog of the worker pool, the workers take the most urgent work first.
+type workQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	capacity int
+	size     int
+	// A FIFO per priority, indexed by priority.
+	queues [model.MaxPriority + 1][]work.Work
+	closed bool
+}
+
+func newWorkQueue(capacity int) *workQueue {
+	if capacity < 1 {
+		capacity = 1
+	}
+	q := &workQueue{capacity: capacity}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// push blocks until the backlog has some space, it returns false if the queue is closed.
+func (q *workQueue) push(w work.Work) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size >= q.capacity && !q.closed {
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return false
+	}
+	q.add(w)
+	return true
+}
+
+// tryPush adds the work unless the backlog is full or the queue is closed.
+func (q *workQueue) tryPush(w work.Work) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.size >= q.capacity {
+		return false
+	}
+	q.add(w)
+	return true
+}
+
+func (q *workQueue) add(w work.Work) {
+	priority := model.ClampPriority(w.Job.Priority)
+	q.queues[priority] = append(q.queues[priority], w)
+	q.size++
+	q.notEmpty.Signal()
+}
+
+// pop blocks until there's some work, it returns false once the queue is closed and drained.
+func (q *workQueue) pop() (work.Work, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	for priority := model.MaxPriority; priority >= model.MinPriority; priority-- {
+		if len(q.queues[priority]) == 0 {
+			continue
+		}
+		w := q.queues[priority][0]
+		q.queues[priority][0] = work.Work{}
+		q.queues[priority] = q.queues[priority][1:]
+		q.size--
+		q.notFull.Signal()
+		return w, true
+	}
+	return work.Work{}, false
+}
+
+// close lets the workers drain the backlog and exit.
+func (q *workQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
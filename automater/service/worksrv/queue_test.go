@@ -0,0 +1,67 @@
+package worksrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/automater/service/worksrv/work"
+	"github.com/NubeIO/rubix-automater/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWorkQueue(t *testing.T) {
+	q := newWorkQueue(3)
+	q.push(work.Work{Job: &model.Job{UUID: "bulk"}})
+	q.push(work.Work{Job: &model.Job{UUID: "normal", Priority: 5}})
+	q.push(work.Work{Job: &model.Job{UUID: "urgent", Priority: model.MaxPriority}})
+
+	pushed := make(chan bool)
+	go func() {
+		// Blocks until the backlog has some space.
+		pushed <- q.push(work.Work{Job: &model.Job{UUID: "late"}})
+	}()
+	for _, expected := range []string{"urgent", "normal", "bulk", "late"} {
+		w, ok := q.pop()
+		if !ok || w.Job.UUID != expected {
+			t.Fatalf("expected %s, got %v", expected, w.Job)
+		}
+	}
+	if !<-pushed {
+		t.Fatal("expected the blocked push to succeed")
+	}
+
+	q.close()
+	if _, ok := q.pop(); ok {
+		t.Fatal("expected no work from a closed and drained queue")
+	}
+	if q.push(work.Work{Job: &model.Job{UUID: "closed"}}) {
+		t.Fatal("expected push to a closed queue to fail")
+	}
+}
+
+func TestWorkService_RoutesToQueuePool(t *testing.T) {
+	queues := []config.WorkerQueue{{Name: "installs", Workers: 1, QueueCapacity: 1}}
+	srv := New(nil, nil, nil, nil, nil, time.Second, 1, 1, queues, logrus.New())
+	if !srv.HasQueue("installs") || !srv.HasQueue("") || srv.HasQueue("unknown") {
+		t.Fatal("expected the installs and default queues to be served only")
+	}
+
+	install := srv.CreateWork(&model.Job{UUID: "install_1", Queue: "installs"})
+	if !srv.TryDispatch(install) {
+		t.Fatal("expected the installs backlog to accept the work")
+	}
+	if srv.TryDispatch(srv.CreateWork(&model.Job{UUID: "install_2", Queue: "installs"})) {
+		t.Fatal("expected the full installs backlog to reject the work")
+	}
+	// A busy installs pool must not hold up the other queues.
+	if !srv.TryDispatch(srv.CreateWork(&model.Job{UUID: "ping", Queue: "unknown"})) {
+		t.Fatal("expected the default backlog to accept the work of an unknown queue")
+	}
+	if w, _ := srv.pools["installs"].queue.pop(); w.Job.UUID != "install_1" {
+		t.Fatalf("expected install_1 on the installs pool, got %s", w.Job.UUID)
+	}
+	if w, _ := srv.pools[model.DefaultQueue].queue.pop(); w.Job.UUID != "ping" {
+		t.Fatalf("expected ping on the default pool, got %s", w.Job.UUID)
+	}
+}
@@ -0,0 +1,203 @@
+package worksrv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// crashedReason is the failure reason of the runs orphaned by a worker that died, eg: the process crashed mid-run.
+const crashedReason = "crashed: the worker holding the job stopped sending heartbeats"
+
+// leaseHeartbeats is how many heartbeat intervals a lease lasts, so a slow heartbeat doesn't orphan the work.
+const leaseHeartbeats = 3
+
+// holdRegistry keeps track of the jobs held by the worker pools by job uuid, from their dispatch until their work
+// is executed, and of the pipeline steps while they run, their leases are renewed by the heartbeats.
+type holdRegistry struct {
+	mu    sync.Mutex
+	holds map[string]int
+}
+
+func newHoldRegistry() *holdRegistry {
+	return &holdRegistry{holds: make(map[string]int)}
+}
+
+func (h *holdRegistry) hold(uuid string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.holds[uuid]++
+}
+
+// release releases a hold of the job, it returns true if the job isn't held anymore.
+func (h *holdRegistry) release(uuid string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.holds[uuid]--
+	if h.holds[uuid] > 0 {
+		return false
+	}
+	delete(h.holds, uuid)
+	return true
+}
+
+func (h *holdRegistry) held(uuid string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.holds[uuid] > 0
+}
+
+func (h *holdRegistry) jobIDs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, 0, len(h.holds))
+	for uuid := range h.holds {
+		ids = append(ids, uuid)
+	}
+	return ids
+}
+
+// Recover renews the leases of the jobs held by this instance on every heartbeat, and recovers the SCHEDULED and
+// IN_PROGRESS jobs orphaned by a dead instance, on startup and then on every heartbeat until the context is done.
+func (srv *workService) Recover(ctx context.Context, owner string, interval time.Duration) {
+	srv.owner = owner
+	ttl := leaseHeartbeats * interval
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			srv.heartbeat(owner, ttl)
+			srv.recoverOrphans(ttl)
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// heartbeat renews the leases of the held jobs.
+func (srv *workService) heartbeat(owner string, ttl time.Duration) {
+	expiresAt := srv.time.Now().Add(ttl)
+	if err := srv.storage.RenewLeases(owner, srv.holds.jobIDs(), expiresAt); err != nil {
+		srv.logger.Errorf("could not renew job leases: %s", err)
+	}
+}
+
+// recoverOrphans fails the runs of the orphaned jobs and reschedules them according to their options.
+func (srv *workService) recoverOrphans(ttl time.Duration) {
+	now := srv.time.Now()
+	var active []*model.Job
+	for _, status := range []model.JobStatus{model.Scheduled, model.InProgress} {
+		jobs, err := srv.storage.GetJobs(status)
+		if err != nil {
+			srv.logger.Errorf("could not get %s jobs from storage: %s", status, err)
+			return
+		}
+		active = append(active, jobs...)
+	}
+	if len(active) == 0 {
+		return
+	}
+	ids := make([]string, len(active))
+	for i, j := range active {
+		ids[i] = j.UUID
+	}
+	leases, err := srv.storage.GetLeases(ids)
+	if err != nil {
+		srv.logger.Errorf("could not get job leases from storage: %s", err)
+		return
+	}
+	for _, j := range active {
+		if srv.holds.held(j.UUID) || !orphaned(j, leases[j.UUID], now, ttl) {
+			continue
+		}
+		// The job may have finished or got dispatched again meanwhile.
+		stored, err := srv.storage.GetJob(j.UUID)
+		if err != nil || (stored.Status != model.Scheduled && stored.Status != model.InProgress) || srv.holds.held(j.UUID) {
+			continue
+		}
+		if err := srv.recoverJob(stored, now); err != nil {
+			srv.logger.Errorf("could not recover job %s: %s", j.UUID, err)
+		}
+	}
+}
+
+// orphaned reports whether the active job lost its worker, its lease expired or it never got one although it's been
+// scheduled or started for longer than a lease lasts.
+func orphaned(j *model.Job, lease *model.Lease, now time.Time, ttl time.Duration) bool {
+	if lease != nil {
+		return lease.IsExpired(now)
+	}
+	since := j.ScheduledAt
+	if j.Status == model.InProgress {
+		since = j.StartedAt
+	}
+	return since == nil || now.Sub(*since) >= ttl
+}
+
+// recoverJob fails the orphaned run of the job with a transaction, then retries the job, recycles the recurring
+// ones and dead-letters the others, just like a failed run.
+func (srv *workService) recoverJob(j *model.Job, crashedAt time.Time) error {
+	srv.logger.Warnf("recovering %s job %s orphaned by a dead worker", j.Status, j.UUID)
+	j.MarkFailed(&crashedAt, crashedReason)
+	if _, err := srv.storage.CreateTransaction(j); err != nil {
+		return err
+	}
+	if j.BelongsToPipeline() {
+		return srv.recoverPipeline(j, crashedAt)
+	}
+	if j.CanRetry() {
+		retryAt := crashedAt.Add(j.JobOptions.RetryDelayAfter(j.Attempt))
+		j.MarkRetry(&retryAt)
+		if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+			return err
+		}
+		srv.notifier.NotifyDue(retryAt)
+		return nil
+	}
+	if j.IsRecycleJob() {
+		recycled, err := srv.storage.Recycle(j.UUID, j)
+		if err != nil {
+			return err
+		}
+		if recycled.RunAt != nil {
+			srv.notifier.NotifyDue(*recycled.RunAt)
+		}
+		return nil
+	}
+	if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+		return err
+	}
+	srv.deadLetter(j)
+	return nil
+}
+
+// recoverPipeline fails the pipeline of the orphaned job, a recurring pipeline is recycled for its next run.
+func (srv *workService) recoverPipeline(j *model.Job, crashedAt time.Time) error {
+	if _, err := srv.storage.UpdateJob(j.UUID, j); err != nil {
+		return err
+	}
+	p, err := srv.storage.GetPipeline(j.PipelineID)
+	if err != nil {
+		return err
+	}
+	if p.IsDAG() {
+		return srv.advance(j)
+	}
+	p.MarkFailed(&crashedAt)
+	if !p.IsRecurring() {
+		return srv.storage.UpdatePipeline(p.UUID, p)
+	}
+	recycled, err := srv.storage.RecyclePipeline(p.UUID, p)
+	if err != nil {
+		return err
+	}
+	if recycled.RunAt != nil {
+		srv.notifier.NotifyDue(*recycled.RunAt)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package worksrv
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// run is a work in progress.
+type run struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	// stopReason is set when the run got stopped before it finished, eg: replaced by a new run.
+	stopReason string
+	// cancelled is set when the run got cancelled through the API, the job ends up CANCELLED.
+	cancelled bool
+}
+
+type runContextKey struct{}
+
+// runRegistry keeps track of the runs in progress by job uuid, to apply the concurrency policy of the jobs.
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string][]*run
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{runs: make(map[string][]*run)}
+}
+
+// start registers a run of the job, with the ConcurrencyReplace policy it stops the runs in progress and waits
+// for them to finish first.
+func (r *runRegistry) start(ctx context.Context, j *model.Job) (context.Context, *run) {
+	if j.ConcurrencyPolicy() == model.ConcurrencyReplace {
+		for _, stopped := range r.stop(j.UUID, "replaced by a new run") {
+			<-stopped.done
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	rn := &run{cancel: cancel, done: make(chan struct{})}
+	r.mu.Lock()
+	r.runs[j.UUID] = append(r.runs[j.UUID], rn)
+	r.mu.Unlock()
+	return context.WithValue(ctx, runContextKey{}, rn), rn
+}
+
+// finish unregisters the run.
+func (r *runRegistry) finish(j *model.Job, rn *run) {
+	r.mu.Lock()
+	runs := r.runs[j.UUID]
+	for i := range runs {
+		if runs[i] == rn {
+			runs = append(runs[:i], runs[i+1:]...)
+			break
+		}
+	}
+	if len(runs) == 0 {
+		delete(r.runs, j.UUID)
+	} else {
+		r.runs[j.UUID] = runs
+	}
+	r.mu.Unlock()
+	rn.cancel()
+	close(rn.done)
+}
+
+// running reports whether a run of the job is in progress.
+func (r *runRegistry) running(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.runs[uuid]) > 0
+}
+
+// stop cancels the runs of the job in progress and returns them.
+func (r *runRegistry) stop(uuid, reason string) []*run {
+	return r.stopRuns(uuid, reason, false)
+}
+
+// cancel cancels the runs of the job in progress on behalf of the API and returns them.
+func (r *runRegistry) cancel(uuid, reason string) []*run {
+	return r.stopRuns(uuid, reason, true)
+}
+
+func (r *runRegistry) stopRuns(uuid, reason string, cancelled bool) []*run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := append([]*run(nil), r.runs[uuid]...)
+	for _, rn := range runs {
+		rn.stopReason = reason
+		rn.cancelled = cancelled
+		rn.cancel()
+	}
+	return runs
+}
+
+// stopReason returns why the run of the context got stopped, if it did.
+func (r *runRegistry) stopReason(ctx context.Context) string {
+	rn, ok := ctx.Value(runContextKey{}).(*run)
+	if !ok {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return rn.stopReason
+}
+
+// cancelled reports whether the run of the context got cancelled through the API.
+func (r *runRegistry) cancelled(ctx context.Context) bool {
+	rn, ok := ctx.Value(runContextKey{}).(*run)
+	if !ok {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return rn.cancelled
+}
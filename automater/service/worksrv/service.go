@@ -7,7 +7,9 @@ import (
 	"github.com/NubeIO/rubix-automater/automater/model"
 	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
 	"github.com/NubeIO/rubix-automater/automater/service/worksrv/work"
+	"github.com/NubeIO/rubix-automater/pkg/config"
 	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
 	"sync"
 	"time"
 
@@ -23,69 +25,156 @@ const (
 var _ automater.WorkService = &workService{}
 
 type workService struct {
-	// The number of go-routines that will operate concurrently.
-	workers int
-	// The capacity of the worker pool queue.
-	queueCapacity int
+	// The worker pools by queue name, the default pool serves the jobs without a queue.
+	pools map[string]*workerPool
+	// The runs in progress, for the concurrency policy of the jobs.
+	runs *runRegistry
+	// The jobs dispatched to the worker pools and not executed yet, their leases are renewed by the heartbeats.
+	holds *holdRegistry
+	// The owner of the leases of this instance, empty without recovery.
+	owner string
+	// Serializes the moves of the graph pipelines from step to step.
+	steps sync.Mutex
 	// The time unit for the calculation of the timeout interval for each task.
 	timeoutUnit time.Duration
 
 	storage  automater.Storage
+	jobQueue automater.JobQueue
+	notifier automater.Notifier
 	taskRepo *taskRepo.TaskRepository
 	time     intime.Time
-	queue    chan work.Work
 	wg       sync.WaitGroup
 	logger   *logrus.Logger
 }
 
-// New creates a new work server.
+// New creates a new work server, with a default pool of workers and a pool per named queue.
 func New(
 	storage automater.Storage,
+	jobQueue automater.JobQueue,
+	notifier automater.Notifier,
 	taskRepo *taskRepo.TaskRepository,
 	time intime.Time, timeoutUnit time.Duration,
-	workers, queueCapacity int, logger *logrus.Logger) *workService {
+	workers, queueCapacity int, queues []config.WorkerQueue, logger *logrus.Logger) *workService {
 
+	pools := map[string]*workerPool{
+		model.DefaultQueue: newWorkerPool(model.DefaultQueue, workers, queueCapacity),
+	}
+	for _, q := range queues {
+		pools[q.Name] = newWorkerPool(q.Name, q.Workers, q.QueueCapacity)
+	}
 	return &workService{
-		storage:       storage,
-		taskRepo:      taskRepo,
-		workers:       workers,
-		queueCapacity: queueCapacity,
-		timeoutUnit:   timeoutUnit,
-		queue:         make(chan work.Work, queueCapacity),
-		time:          time,
-		logger:        logger,
+		storage:     storage,
+		jobQueue:    jobQueue,
+		notifier:    notifier,
+		taskRepo:    taskRepo,
+		pools:       pools,
+		runs:        newRunRegistry(),
+		holds:       newHoldRegistry(),
+		timeoutUnit: timeoutUnit,
+		time:        time,
+		logger:      logger,
 	}
 }
 
-// Start starts the worker pool.
+// Start starts the worker pools.
 func (srv *workService) Start() {
-	for i := 0; i < srv.workers; i++ {
-		srv.wg.Add(1)
-		go srv.startWorker(i, srv.queue, &srv.wg)
+	for _, pool := range srv.pools {
+		for i := 0; i < pool.workers; i++ {
+			srv.wg.Add(1)
+			go srv.startWorker(fmt.Sprintf("%s-%d", pool.name, i), pool.queue, &srv.wg)
+		}
+		srv.logger.Infof("set up %d workers for queue %s with a queue of capacity %d", pool.workers, pool.name, pool.queue.capacity)
 	}
-	srv.logger.Infof("set up %d workers with a queue of capacity %d", srv.workers, srv.queueCapacity)
 }
 
-// Dispatch dispatches a work to the worker pool.
+// pool returns the worker pool of the job queue, jobs of unknown queues run on the default pool.
+func (srv *workService) pool(j *model.Job) *workerPool {
+	if pool, ok := srv.pools[j.Queue]; ok {
+		return pool
+	}
+	if j.Queue != "" {
+		srv.logger.Warnf("job %s has unknown queue %s, running it on the %s queue", j.UUID, j.Queue, model.DefaultQueue)
+	}
+	return srv.pools[model.DefaultQueue]
+}
+
+// Dispatch dispatches a work to the worker pool of its queue, the most urgent work in the backlog runs first.
+// It blocks until the worker pool backlog has some space.
 func (srv *workService) Dispatch(w work.Work) {
-	workType := WorkTypeTask
-	for job := w.Job; ; job, workType = job.Next, WorkTypePipeline {
-		go func() {
-			result, ok := <-w.Result
-			if ok {
-				if err := srv.storage.CreateJobResult(&result); err != nil {
-					srv.logger.Errorf("could not create job result to the storage %s", err)
-				}
-			}
-		}()
+	w.Type = workType(w)
+	srv.collectResults(w)
+	srv.holds.hold(w.Job.UUID)
+	if !srv.pool(w.Job).queue.push(w) {
+		srv.holds.release(w.Job.UUID)
+		srv.logger.Errorf("could not dispatch work for job %s: worker pool is stopped", w.Job.UUID)
+	}
+}
 
-		if !job.HasNext() {
-			break
-		}
+// TryDispatch dispatches a work to the worker pool of its queue, unless the worker pool backlog is full.
+func (srv *workService) TryDispatch(w work.Work) bool {
+	w.Type = workType(w)
+	srv.holds.hold(w.Job.UUID)
+	if !srv.pool(w.Job).queue.tryPush(w) {
+		srv.holds.release(w.Job.UUID)
+		return false
+	}
+	srv.collectResults(w)
+	return true
+}
+
+// HasQueue reports whether a worker pool serves the queue.
+func (srv *workService) HasQueue(queue string) bool {
+	if queue == "" {
+		return true
+	}
+	_, ok := srv.pools[queue]
+	return ok
+}
+
+// IsRunning reports whether a run of the job is in progress, on this instance or another one sharing the storage.
+func (srv *workService) IsRunning(j *model.Job) bool {
+	return srv.runs.running(j.UUID) || srv.IsRunningElsewhere(j)
+}
+
+// IsRunningElsewhere reports whether another instance sharing the storage holds the job, its unexpired lease tells
+// the job got dispatched there and its work isn't executed yet.
+func (srv *workService) IsRunningElsewhere(j *model.Job) bool {
+	leases, err := srv.storage.GetLeases([]string{j.UUID})
+	if err != nil {
+		srv.logger.Errorf("could not get the lease of job %s: %s", j.UUID, err)
+		return false
 	}
-	w.Type = workType
+	lease, ok := leases[j.UUID]
+	return ok && lease.Owner != srv.owner && !lease.IsExpired(srv.time.Now())
+}
 
-	srv.queue <- w
+// Cancel cancels the runs of the job in progress, the worker running it stores the job as CANCELLED. It returns
+// false if the job isn't running on this instance.
+func (srv *workService) Cancel(j *model.Job, reason string) bool {
+	return len(srv.runs.cancel(j.UUID, reason)) > 0
+}
+
+func workType(w work.Work) string {
+	if w.Job.HasNext() {
+		return WorkTypePipeline
+	}
+	return WorkTypeTask
+}
+
+// collectResults stores the result of a task work, the pipeline works store the result of every job before running the
+// next one, which may look it up.
+func (srv *workService) collectResults(w work.Work) {
+	if w.Type == WorkTypePipeline {
+		return
+	}
+	go func() {
+		result, ok := <-w.Result
+		if ok {
+			if err := srv.storage.CreateJobResult(&result); err != nil {
+				srv.logger.Errorf("could not create job result to the storage %s", err)
+			}
+		}
+	}()
 }
 
 // CreateWork creates and return a new Work instance.
@@ -96,7 +185,9 @@ func (srv *workService) CreateWork(j *model.Job) work.Work {
 
 // Stop signals the workers to stop working gracefully.
 func (srv *workService) Stop() {
-	close(srv.queue)
+	for _, pool := range srv.pools {
+		pool.queue.close()
+	}
 	srv.logger.Info("waiting for ongoing tasks to finish...")
 	srv.wg.Wait()
 }
@@ -105,73 +196,108 @@ func (srv *workService) Stop() {
 func (srv *workService) ExecJobWork(ctx context.Context, w work.Work) error {
 	// Do not let the go-routines wait for result in case of early exit.
 	defer close(w.Result)
-	srv.logger.Info("executes the job worker", w.Job.Name)
-
-	startedAt := srv.time.Now()
-	w.Job.MarkStarted(&startedAt)
-	if _, err := srv.storage.UpdateJob(w.Job.UUID, w.Job); err != nil {
-		return err
+	// A job cancelled while waiting in the backlog doesn't run, a recurring one carries on with its next run.
+	if j, err := srv.storage.GetJob(w.Job.UUID); err == nil && j.Status == model.Cancelled {
+		if j.IsRecycleJob() {
+			return srv.recycle(j)
+		}
+		return nil
 	}
-	timeout := DefaultJobTimeout
-	if w.Job.Timeout > 0 {
-		timeout = time.Duration(w.Job.Timeout) * w.TimeoutUnit
+	srv.logger.Info("executes the job worker", w.Job.Name)
+	if w.Job.BelongsToPipeline() {
+		if p, err := srv.storage.GetPipeline(w.Job.PipelineID); err == nil && p.IsDAG() {
+			return srv.execStep(ctx, w)
+		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	jobResultChan := make(chan model.JobResult, 1)
-
-	srv.work(w.Job, jobResultChan, nil)
 
 	var jobResult model.JobResult
-	select {
-	case <-ctx.Done():
-		failedAt := srv.time.Now()
-		w.Job.MarkFailed(&failedAt, ctx.Err().Error())
-		jobResult = model.JobResult{
-			JobID:    w.Job.UUID,
-			Metadata: nil,
-			Error:    ctx.Err().Error(),
-		}
-	case jobResult = <-jobResultChan:
-		if jobResult.Error != "" {
-			failedAt := srv.time.Now()
-			srv.logger.Errorln("RAN FAILED", w.Job.Name, "ERR", jobResult.Error)
-			w.Job.MarkFailed(&failedAt, jobResult.Error)
+	var err error
+	if w.Job.BelongsToPipeline() {
+		jobResult, err = srv.attemptInPlace(ctx, w.Job, w.TimeoutUnit, nil)
+	} else {
+		jobResult, err = srv.attempt(ctx, w.Job, w.TimeoutUnit, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if srv.runs.cancelled(ctx) {
+		// A recurring job carries on with its next run, the others stay CANCELLED.
+		if w.Job.IsRecycleJob() {
+			err = srv.recycle(w.Job)
 		} else {
-			srv.logger.Info("RAN JOB", w.Job.Name)
-			completedAt := srv.time.Now()
-			w.Job.MarkCompleted(&completedAt)
+			_, err = srv.storage.UpdateJob(w.Job.UUID, w.Job)
+		}
+		if err != nil {
+			return err
 		}
+		w.Result <- jobResult
+		return nil
 	}
-	if _, err := srv.storage.CreateTransaction(w.Job); err != nil {
-		return err
+	if srv.runs.stopReason(ctx) != "" {
+		// The run that stopped this one owns the job now.
+		w.Result <- jobResult
+		return nil
 	}
 
-	if w.Job.IsRecycleJob() {
-		if _, err := srv.storage.Recycle(w.Job.UUID, w.Job); err != nil {
+	if w.Job.Status == model.Failed && !w.Job.BelongsToPipeline() && w.Job.CanRetry() {
+		// Free the worker, the scheduler picks the job up again once the retry is due.
+		retryAt := srv.time.Now().Add(w.Job.JobOptions.RetryDelayAfter(w.Job.Attempt))
+		w.Job.MarkRetry(&retryAt)
+		srv.logger.Infof("job %s failed attempt %d of %d, retrying at %s",
+			w.Job.UUID, w.Job.Attempt, w.Job.JobOptions.MaxRetryAttempts(), retryAt)
+		if _, err := srv.storage.UpdateJob(w.Job.UUID, w.Job); err != nil {
 			return err
 		}
+		srv.notifier.NotifyDue(retryAt)
+		w.Result <- jobResult
+		return nil
+	}
 
+	if w.Job.IsRecycleJob() {
+		if err := srv.recycle(w.Job); err != nil {
+			return err
+		}
 	} else {
 		if _, err := srv.storage.UpdateJob(w.Job.UUID, w.Job); err != nil {
 			return err
 		}
+		if w.Job.Status == model.Failed && !w.Job.BelongsToPipeline() {
+			srv.deadLetter(w.Job)
+		}
 	}
 
 	w.Result <- jobResult
 	if w.Job.PipelineID != "" {
-		p, _ := srv.storage.GetPipeline(w.Job.PipelineID)
-		if p.PipelineOptions != nil && p.PipelineOptions.EnableInterval {
-			if _, err := srv.storage.RecyclePipeline(p.UUID, p); err != nil {
+		p, err := srv.storage.GetPipeline(w.Job.PipelineID)
+		if err != nil {
+			// The pipeline may have been deleted while its job ran.
+			srv.logger.Errorf("could not get pipeline %s of job %s from the storage %s", w.Job.PipelineID, w.Job.UUID, err)
+		} else if p.IsRecurring() {
+			recycled, err := srv.storage.RecyclePipeline(p.UUID, p)
+			if err != nil {
 				return err
 			}
+			if recycled.RunAt != nil {
+				srv.notifier.NotifyDue(*recycled.RunAt)
+			}
 		}
 	}
 
 	return nil
 }
 
+// recycle moves a recurring job on to its next run.
+func (srv *workService) recycle(j *model.Job) error {
+	recycled, err := srv.storage.Recycle(j.UUID, j)
+	if err != nil {
+		return err
+	}
+	if recycled.RunAt != nil {
+		srv.notifier.NotifyDue(*recycled.RunAt)
+	}
+	return nil
+}
+
 // ExecPipelineWork executes the pipeline work.
 func (srv *workService) ExecPipelineWork(ctx context.Context, w work.Work) error {
 	// Do not let the go-routines wait for result in case of early exit.
@@ -190,53 +316,33 @@ func (srv *workService) ExecPipelineWork(ctx context.Context, w work.Work) error
 		if checkJob.Status != model.Scheduled {
 			return nil
 		}
-		startedAt := srv.time.Now()
-		job.MarkStarted(&startedAt)
-		if _, err := srv.storage.UpdateJob(job.UUID, job); err != nil {
-			return err
-		}
 		if i == 0 {
+			startedAt := srv.time.Now()
 			p.MarkStarted(&startedAt)
 			if err := srv.storage.UpdatePipeline(p.UUID, p); err != nil {
 				return err
 			}
 		}
-		timeout := DefaultJobTimeout
-		if job.Timeout > 0 {
-			timeout = time.Duration(job.Timeout) * w.TimeoutUnit
-		}
 
-		ctx, cancel := context.WithTimeout(ctx, timeout)
-		jobResultChan := make(chan model.JobResult, 1)
-		srv.work(job, jobResultChan, jobResult.Metadata)
-
-		select {
-		case <-ctx.Done():
-			failedAt := srv.time.Now()
-			job.MarkFailed(&failedAt, ctx.Err().Error())
-			p.MarkFailed(&failedAt)
-			jobResult = model.JobResult{
-				JobID:    job.UUID,
-				Metadata: nil,
-				Error:    ctx.Err().Error(),
-			}
-		case jobResult = <-jobResultChan:
-			if jobResult.Error != "" {
-				failedAt := srv.time.Now()
-				job.MarkFailed(&failedAt, jobResult.Error)
-				p.MarkFailed(&failedAt)
-			} else {
-				completedAt := srv.time.Now()
-				job.MarkCompleted(&completedAt)
-				if !job.HasNext() {
-					p.MarkCompleted(&completedAt)
-				}
+		jobResult, err = srv.attemptInPlace(ctx, job, w.TimeoutUnit, jobResult.Metadata)
+		if err != nil {
+			return err
+		}
+		if srv.runs.cancelled(ctx) {
+			// The pipeline got cancelled along, its next steps don't run.
+			if _, err := srv.storage.UpdateJob(job.UUID, job); err != nil {
+				return err
 			}
+			return srv.storage.CreateJobResult(&jobResult)
 		}
-		// Reset timeout.
-		cancel()
-		if _, err := srv.storage.CreateTransaction(w.Job); err != nil {
-			return err
+		if srv.runs.stopReason(ctx) != "" {
+			// The run that stopped this one owns the pipeline now.
+			return srv.storage.CreateJobResult(&jobResult)
+		}
+		if job.Status == model.Failed {
+			p.MarkFailed(job.CompletedAt)
+		} else if !job.HasNext() {
+			p.MarkCompleted(job.CompletedAt)
 		}
 		if _, err := srv.storage.UpdateJob(job.UUID, job); err != nil {
 			return err
@@ -246,8 +352,10 @@ func (srv *workService) ExecPipelineWork(ctx context.Context, w work.Work) error
 				return err
 			}
 		}
-
-		w.Result <- jobResult
+		// The result is stored before the next job runs, its task params may look it up.
+		if err := srv.storage.CreateJobResult(&jobResult); err != nil {
+			return err
+		}
 		// Stop the pipeline execution on failure.
 		if job.Status == model.Failed {
 			break
@@ -261,8 +369,123 @@ func (srv *workService) ExecPipelineWork(ctx context.Context, w work.Work) error
 	return nil
 }
 
+// attempt runs a single attempt of the job and stores its transaction.
+func (srv *workService) attempt(
+	ctx context.Context,
+	job *model.Job,
+	timeoutUnit time.Duration,
+	previousJobResultsMetadata interface{}) (model.JobResult, error) {
+
+	startedAt := srv.time.Now()
+	job.MarkStarted(&startedAt)
+	if _, err := srv.storage.UpdateJob(job.UUID, job); err != nil {
+		return model.JobResult{}, err
+	}
+	params, err := srv.renderParams(job)
+	if err != nil {
+		failedAt := srv.time.Now()
+		reason := fmt.Sprintf("task_params: %s", err)
+		// The params render the same on a retry.
+		job.MarkFailedForGood(&failedAt, reason)
+		jobResult := model.JobResult{JobID: job.UUID, Error: reason}
+		if _, err := srv.storage.CreateTransaction(job); err != nil {
+			return jobResult, err
+		}
+		return jobResult, nil
+	}
+	timeout := DefaultJobTimeout
+	if job.Timeout > 0 {
+		timeout = time.Duration(job.Timeout) * timeoutUnit
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	jobResultChan := make(chan model.JobResult, 1)
+	srv.work(ctx, job, params, jobResultChan, previousJobResultsMetadata)
+
+	var jobResult model.JobResult
+	select {
+	case <-ctx.Done():
+		failedAt := srv.time.Now()
+		reason := ctx.Err().Error()
+		if stopReason := srv.runs.stopReason(ctx); stopReason != "" {
+			reason = stopReason
+		}
+		if srv.runs.cancelled(ctx) {
+			job.MarkCancelled(&failedAt, reason)
+		} else {
+			job.MarkFailed(&failedAt, reason)
+		}
+		jobResult = model.JobResult{
+			JobID:    job.UUID,
+			Metadata: nil,
+			Error:    reason,
+		}
+	case jobResult = <-jobResultChan:
+		if jobResult.Error != "" {
+			failedAt := srv.time.Now()
+			srv.logger.Errorln("RAN FAILED", job.Name, "ERR", jobResult.Error)
+			job.MarkFailed(&failedAt, jobResult.Error)
+		} else {
+			srv.logger.Info("RAN JOB", job.Name)
+			completedAt := srv.time.Now()
+			job.MarkCompleted(&completedAt)
+		}
+	}
+	if _, err := srv.storage.CreateTransaction(job); err != nil {
+		return jobResult, err
+	}
+	return jobResult, nil
+}
+
+// attemptInPlace runs the job until it succeeds or runs out of attempts, waiting for the retry delay in between.
+// The steps of a pipeline retry in place so the next steps don't run before the step succeeded. The step is held
+// meanwhile, so the heartbeats renew its lease like the lease of the job the work started from.
+func (srv *workService) attemptInPlace(
+	ctx context.Context,
+	job *model.Job,
+	timeoutUnit time.Duration,
+	previousJobResultsMetadata interface{}) (model.JobResult, error) {
+
+	srv.holds.hold(job.UUID)
+	defer srv.release(job.UUID)
+	for {
+		jobResult, err := srv.attempt(ctx, job, timeoutUnit, previousJobResultsMetadata)
+		if err != nil || job.Status != model.Failed || !job.CanRetry() {
+			return jobResult, err
+		}
+		delay := job.JobOptions.RetryDelayAfter(job.Attempt)
+		srv.logger.Infof("job %s failed attempt %d of %d, retrying in %s",
+			job.UUID, job.Attempt, job.JobOptions.MaxRetryAttempts(), delay)
+		select {
+		case <-ctx.Done():
+			if srv.runs.cancelled(ctx) {
+				cancelledAt := srv.time.Now()
+				job.MarkCancelled(&cancelledAt, srv.runs.stopReason(ctx))
+				if _, err := srv.storage.CreateTransaction(job); err != nil {
+					return jobResult, err
+				}
+			}
+			return jobResult, nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// deadLetter moves a job that won't run again to the dead-letter store, so it can be inspected and requeued.
+func (srv *workService) deadLetter(j *model.Job) {
+	id, _ := uuid.New().Make("dlq")
+	now := srv.time.Now()
+	d := model.NewDeadLetter(id, j, "", j.FailureReason, &now)
+	if err := srv.jobQueue.PushDeadLetter(d); err != nil {
+		srv.logger.Errorf("could not dead-letter job %s: %s", j.UUID, err)
+	}
+}
+
 func (srv *workService) work(
+	ctx context.Context,
 	job *model.Job,
+	params map[string]interface{},
 	jobResultChan chan model.JobResult,
 	previousJobResultsMetadata interface{}) {
 
@@ -281,16 +504,24 @@ func (srv *workService) work(
 		var errMsg string
 
 		// Should be already validated.
-		taskFunc, _ := srv.taskRepo.GetTaskFunc(job.TaskName)
-
-		params := []interface{}{
-			job.TaskParams,
+		taskFunc, _ := srv.taskRepo.GetContextTaskFunc(job.TaskName)
+
+		in := taskRepo.TaskInput{
+			Params:     params,
+			JobID:      job.UUID,
+			PipelineID: job.PipelineID,
+			Attempt:    job.Attempt,
+			Logger: srv.logger.WithFields(logrus.Fields{
+				"job":      job.UUID,
+				"pipeline": job.PipelineID,
+				"task":     job.TaskName,
+			}),
 		}
-		if job.UsePreviousResults && previousJobResultsMetadata != nil {
-			params = append(params, previousJobResultsMetadata)
+		if job.UsePreviousResults {
+			in.PreviousResults = previousJobResultsMetadata
 		}
 		// Perform the actual work.
-		resultMetadata, jobErr := taskFunc(params...)
+		resultMetadata, jobErr := taskFunc(ctx, in)
 		if jobErr != nil {
 			errMsg = jobErr.Error()
 		}
@@ -316,15 +547,33 @@ func (srv *workService) Exec(ctx context.Context, w work.Work) error {
 
 }
 
+// release releases a hold of the job, its lease is released once it isn't held anymore.
+func (srv *workService) release(uuid string) {
+	if !srv.holds.release(uuid) {
+		return
+	}
+	if err := srv.storage.ReleaseLeases([]string{uuid}); err != nil {
+		srv.logger.Errorf("could not release job lease: %s", err)
+	}
+}
+
 // startWorker loops through the pending jobs and will call the
-func (srv *workService) startWorker(uuid int, queue <-chan work.Work, wg *sync.WaitGroup) {
+func (srv *workService) startWorker(uuid string, queue *workQueue, wg *sync.WaitGroup) {
 	defer wg.Done()
-	logPrefix := fmt.Sprintf("[startWorker worker] %d", uuid)
-	for w := range queue {
+	logPrefix := fmt.Sprintf("[startWorker worker] %s", uuid)
+	for w, ok := queue.pop(); ok; w, ok = queue.pop() {
 		srv.logger.Infof("%s executing %s... job name:%s", logPrefix, w.Type, w.Job.Name)
-		if err := srv.Exec(context.Background(), w); err != nil {
+		ctx, rn := srv.runs.start(context.Background(), w.Job)
+		if err := srv.Exec(ctx, w); err != nil {
 			srv.logger.Errorf("could not update job status: %s", err)
 		}
+		srv.runs.finish(w.Job, rn)
+		srv.release(w.Job.UUID)
+		if w.Ack != nil {
+			if err := w.Ack(); err != nil {
+				srv.logger.Errorf("could not ack job: %s", err)
+			}
+		}
 
 		srv.logger.Infof("%s %s finished!", logPrefix, w.Type)
 	}
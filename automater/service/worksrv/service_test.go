@@ -0,0 +1,745 @@
+package worksrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/jobqueue"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	taskRepo "github.com/NubeIO/rubix-automater/automater/service/tasksrv/taskrepo"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	intime "github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/wakeup"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWorkService_RetriesFailedJob(t *testing.T) {
+	storage := memory.New()
+	jobQueue := jobqueue.NewMemoryQueue(10, "text")
+	tasks := taskRepo.New()
+	runs := 0
+	tasks.Register("flaky", func(...interface{}) (interface{}, error) {
+		runs++
+		if runs < 3 {
+			return nil, errors.New("device offline")
+		}
+		return "ok", nil
+	})
+	srv := New(storage, jobQueue, wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+
+	now := time.Now()
+	j := &model.Job{
+		UUID:     "job_1",
+		Name:     "flaky",
+		TaskName: "flaky",
+		Status:   model.Scheduled,
+		RunAt:    &now,
+		JobOptions: &model.JobOptions{
+			EnableOnFailRetry: true,
+			MaxAttempts:       3,
+			RetryBackoff:      model.RetryExponential,
+			RetryDelay:        60,
+		},
+	}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := srv.ExecJobWork(context.Background(), srv.CreateWork(j)); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := storage.GetJob(j.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Attempt != attempt {
+			t.Fatalf("expected attempt %d, got %d", attempt, stored.Attempt)
+		}
+		if attempt < 3 {
+			if stored.Status != model.Pending || !stored.RunAt.After(now) {
+				t.Fatalf("expected a pending retry in the future, got %s at %s", stored.Status, stored.RunAt)
+			}
+		} else if stored.Status != model.Completed || stored.FailCount != 2 {
+			t.Fatalf("expected completed after 2 failures, got %s after %d", stored.Status, stored.FailCount)
+		}
+		if stored.RunCount != 1 {
+			t.Fatalf("expected the attempts to count as a single run, got %d", stored.RunCount)
+		}
+		j = stored
+	}
+
+	transactions, err := storage.GetTransactionsByJob(j.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 3 {
+		t.Fatalf("expected a transaction per attempt, got %d", len(transactions))
+	}
+	if letters, _ := jobQueue.GetDeadLetters(); len(letters) != 0 {
+		t.Fatalf("expected no dead letter, got %d", len(letters))
+	}
+}
+
+func TestJobOptions_RetryDelayAfter(t *testing.T) {
+	o := &model.JobOptions{RetryBackoff: model.RetryExponential, RetryDelay: 10, RetryMaxDelay: 60}
+	for attempt, expected := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 80: time.Minute} {
+		if delay := o.RetryDelayAfter(attempt); delay != expected {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, expected, delay)
+		}
+	}
+	o.RetryJitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := o.RetryDelayAfter(1); delay < 5*time.Second || delay > 10*time.Second {
+			t.Fatalf("expected a delay between 5s and 10s, got %s", delay)
+		}
+	}
+}
+
+func TestWorkService_ReplaceStopsRunInProgress(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	started := make(chan bool, 2)
+	release := make(chan bool)
+	tasks.Register("sync", func(...interface{}) (interface{}, error) {
+		started <- true
+		<-release
+		return "ok", nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 2, 2, nil, logrus.New())
+	srv.Start()
+	defer srv.Stop()
+	defer close(release)
+
+	now := time.Now()
+	newJob := func() *model.Job {
+		return &model.Job{
+			UUID:       "job_1",
+			Name:       "sync",
+			TaskName:   "sync",
+			Status:     model.Scheduled,
+			RunAt:      &now,
+			JobOptions: &model.JobOptions{ConcurrencyPolicy: model.ConcurrencyReplace},
+		}
+	}
+	if err := storage.CreateJob(newJob()); err != nil {
+		t.Fatal(err)
+	}
+	srv.Dispatch(srv.CreateWork(newJob()))
+	<-started
+	if !srv.IsRunning(newJob()) {
+		t.Fatal("expected the job to be running")
+	}
+	srv.Dispatch(srv.CreateWork(newJob()))
+	<-started
+
+	transactions, err := storage.GetTransactionsByJob("job_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || transactions[0].FailureReason != "replaced by a new run" {
+		t.Fatalf("expected the first run to be replaced, got %v", transactions)
+	}
+}
+
+func TestWorkService_CancelStopsRunInProgress(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	started := make(chan bool, 1)
+	release := make(chan bool)
+	tasks.Register("sync", func(...interface{}) (interface{}, error) {
+		started <- true
+		<-release
+		return "ok", nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+	srv.Start()
+	defer srv.Stop()
+	defer close(release)
+
+	now := time.Now()
+	j := &model.Job{UUID: "job_1", Name: "sync", TaskName: "sync", Status: model.Scheduled, RunAt: &now}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	srv.Dispatch(srv.CreateWork(j))
+	<-started
+	if !srv.Cancel(j, "cancelled") {
+		t.Fatal("expected the running job to be cancelled")
+	}
+	for srv.IsRunning(j) {
+		time.Sleep(time.Millisecond)
+	}
+
+	stored, err := storage.GetJob(j.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != model.Cancelled {
+		t.Fatalf("expected the job to be cancelled, got %s", stored.Status)
+	}
+	transactions, _ := storage.GetTransactionsByJob(j.UUID)
+	if len(transactions) != 1 || transactions[0].Status != model.Cancelled {
+		t.Fatalf("expected a cancelled transaction, got %v", transactions)
+	}
+	if srv.Cancel(j, "cancelled") {
+		t.Fatal("expected nothing to cancel once the run stopped")
+	}
+}
+
+func TestWorkService_CancelRecyclesRecurringJob(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	started := make(chan bool, 1)
+	release := make(chan bool)
+	tasks.Register("sync", func(...interface{}) (interface{}, error) {
+		started <- true
+		<-release
+		return "ok", nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+	srv.Start()
+	defer srv.Stop()
+	defer close(release)
+
+	now := time.Now()
+	j := &model.Job{
+		UUID:       "job_1",
+		Name:       "sync",
+		TaskName:   "sync",
+		Status:     model.Scheduled,
+		RunAt:      &now,
+		JobOptions: &model.JobOptions{EnableInterval: true, RunOnInterval: "15 min"},
+	}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	srv.Dispatch(srv.CreateWork(j))
+	<-started
+	if !srv.Cancel(j, "cancelled") {
+		t.Fatal("expected the running job to be cancelled")
+	}
+	for srv.IsRunning(j) {
+		time.Sleep(time.Millisecond)
+	}
+
+	stored, err := storage.GetJob(j.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != model.Pending || !stored.RunAt.After(now) {
+		t.Fatalf("expected the job to carry on with its next run, got %s at %s", stored.Status, stored.RunAt)
+	}
+	transactions, _ := storage.GetTransactionsByJob(j.UUID)
+	if len(transactions) != 1 || transactions[0].Status != model.Cancelled {
+		t.Fatalf("expected a cancelled transaction, got %v", transactions)
+	}
+}
+
+func TestWorkService_RunningOnAnotherInstance(t *testing.T) {
+	storage := memory.New()
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), taskRepo.New(), intime.New(), time.Second, 1, 1, nil, logrus.New())
+	srv.owner = "ins_1"
+
+	j := &model.Job{UUID: "job_1", Name: "sync", TaskName: "sync", Status: model.InProgress}
+	if srv.IsRunning(j) {
+		t.Fatal("expected the job not to be running")
+	}
+	// Another instance holds the job.
+	if err := storage.RenewLeases("ins_2", []string{j.UUID}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if !srv.IsRunning(j) || !srv.IsRunningElsewhere(j) {
+		t.Fatal("expected the job to be running on the other instance")
+	}
+	// The lease of this instance or an expired one doesn't tell about another instance.
+	if err := storage.RenewLeases("ins_1", []string{j.UUID}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if srv.IsRunningElsewhere(j) {
+		t.Fatal("expected the lease of this instance not to count as another instance")
+	}
+	if err := storage.RenewLeases("ins_2", []string{j.UUID}, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if srv.IsRunning(j) {
+		t.Fatal("expected the expired lease not to count")
+	}
+}
+
+func TestWorkService_ContextTaskObservesTimeout(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	inputs := make(chan taskRepo.TaskInput, 1)
+	returned := make(chan bool, 1)
+	tasks.RegisterWithContext("poll", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		inputs <- in
+		<-ctx.Done()
+		returned <- true
+		return nil, ctx.Err()
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), 10*time.Millisecond, 1, 1, nil, logrus.New())
+
+	now := time.Now()
+	j := &model.Job{
+		UUID:       "job_1",
+		Name:       "poll",
+		TaskName:   "poll",
+		TaskParams: map[string]interface{}{"host": "10.0.0.1"},
+		Timeout:    1,
+		Status:     model.Scheduled,
+		RunAt:      &now,
+	}
+	if err := storage.CreateJob(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ExecJobWork(context.Background(), srv.CreateWork(j)); err != nil {
+		t.Fatal(err)
+	}
+
+	in := <-inputs
+	if in.JobID != j.UUID || in.Attempt != 1 || in.Logger == nil {
+		t.Fatalf("unexpected task input %+v", in)
+	}
+	var params struct{ Host string }
+	if err := in.DecodeParams(&params); err != nil || params.Host != "10.0.0.1" {
+		t.Fatalf("expected the host param, got %q (%v)", params.Host, err)
+	}
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("expected the task to return once its context is done")
+	}
+	stored, err := storage.GetJob(j.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != model.Failed || stored.FailureReason != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the job to time out, got %s: %s", stored.Status, stored.FailureReason)
+	}
+}
+
+func TestWorkService_RecoversOrphanedJobs(t *testing.T) {
+	storage := memory.New()
+	jobQueue := jobqueue.NewMemoryQueue(10, "text")
+	srv := New(storage, jobQueue, wakeup.New(), taskRepo.New(), intime.New(), time.Second, 1, 1, nil, logrus.New())
+
+	ttl := time.Minute
+	now := time.Now()
+	startedAt := now.Add(-time.Hour)
+	orphan := &model.Job{UUID: "job_orphan", Name: "sync", TaskName: "sync", Status: model.InProgress, StartedAt: &startedAt, Attempt: 1, CreatedAt: &startedAt}
+	recurring := &model.Job{
+		UUID:        "job_recurring",
+		Name:        "poll",
+		TaskName:    "poll",
+		Status:      model.Scheduled,
+		RunAt:       &startedAt,
+		ScheduledAt: &startedAt,
+		CreatedAt:   &startedAt,
+		JobOptions:  &model.JobOptions{EnableInterval: true, RunOnInterval: "15 min"},
+	}
+	leased := &model.Job{UUID: "job_leased", Name: "sync", TaskName: "sync", Status: model.InProgress, StartedAt: &startedAt, CreatedAt: &startedAt}
+	fresh := &model.Job{UUID: "job_fresh", Name: "sync", TaskName: "sync", Status: model.Scheduled, ScheduledAt: &now, CreatedAt: &now}
+	for _, j := range []*model.Job{orphan, recurring, leased, fresh} {
+		if err := storage.CreateJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.RenewLeases("other", []string{leased.UUID}, now.Add(ttl)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.recoverOrphans(ttl)
+
+	stored, _ := storage.GetJob(orphan.UUID)
+	if stored.Status != model.Failed || stored.FailureReason != crashedReason {
+		t.Fatalf("expected the orphaned job to fail by crash, got %s: %s", stored.Status, stored.FailureReason)
+	}
+	if transactions, _ := storage.GetTransactionsByJob(orphan.UUID); len(transactions) != 1 || transactions[0].Status != model.Failed {
+		t.Fatalf("expected a failed transaction, got %v", transactions)
+	}
+	if letters, _ := jobQueue.GetDeadLetters(); len(letters) != 1 {
+		t.Fatalf("expected the orphaned job to be dead-lettered, got %d", len(letters))
+	}
+	stored, _ = storage.GetJob(recurring.UUID)
+	if stored.Status != model.Pending || !stored.RunAt.After(startedAt) {
+		t.Fatalf("expected the recurring job to be rescheduled, got %s at %s", stored.Status, stored.RunAt)
+	}
+	for _, j := range []*model.Job{leased, fresh} {
+		stored, _ = storage.GetJob(j.UUID)
+		if stored.Status != j.Status {
+			t.Fatalf("expected job %s to be left alone, got %s", j.UUID, stored.Status)
+		}
+	}
+}
+
+func TestWorkService_LeasesPipelineSteps(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	started := make(chan bool, 1)
+	release := make(chan bool)
+	tasks.Register("ping", func(...interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	tasks.Register("install", func(...interface{}) (interface{}, error) {
+		started <- true
+		<-release
+		return "ok", nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+	srv.Start()
+	defer srv.Stop()
+	defer close(release)
+
+	now := time.Now()
+	jobs := []*model.Job{
+		model.NewJob("job_1", "ping", "ping", "", "", "pip_1", "job_2", 0, &now, &now, false, false, nil, nil),
+		model.NewJob("job_2", "install", "install", "", "", "pip_1", "", 0, &now, &now, false, false, nil, nil),
+	}
+	for _, j := range jobs {
+		j.Status = model.Scheduled
+	}
+	p := model.NewPipeline("pip_1", "pipeline", "", nil, jobs, &now)
+	if err := storage.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+	p.MergeJobsInOne()
+	srv.Dispatch(srv.CreateWork(p.Jobs[0]))
+	<-started
+
+	// The heartbeat renews the lease of the step in progress, so another instance doesn't recover it.
+	ttl := time.Minute
+	srv.heartbeat("ins_1", ttl)
+	leases, err := storage.GetLeases([]string{"job_2"})
+	if err != nil || leases["job_2"] == nil || leases["job_2"].Owner != "ins_1" {
+		t.Fatalf("expected the second step to be leased, got %v, %v", leases, err)
+	}
+	other := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+	other.recoverOrphans(ttl)
+	if stored, _ := storage.GetJob("job_2"); stored.Status != model.InProgress {
+		t.Fatalf("expected the second step to keep running, got %s", stored.Status)
+	}
+
+	release <- true
+	for srv.IsRunning(jobs[0]) {
+		time.Sleep(time.Millisecond)
+	}
+	if leases, _ := storage.GetLeases([]string{"job_1", "job_2"}); len(leases) != 0 {
+		t.Fatalf("expected the leases to be released, got %v", leases)
+	}
+}
+
+func TestWorkService_RunsPipelineGraph(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	var fanIn interface{}
+	tasks.RegisterWithContext("step", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		if in.JobID == "job_d" {
+			fanIn = in.PreviousResults
+		}
+		return in.JobID, nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+
+	// a runs first, then b and c in parallel, then d once both completed.
+	now := time.Now()
+	step := func(name string, dependsOn ...string) *model.Job {
+		j := &model.Job{UUID: "job_" + name, Name: name, TaskName: "step", PipelineID: "pip_1", DependsOn: dependsOn, Status: model.Pending}
+		if len(dependsOn) == 0 {
+			j.RunAt = &now
+		}
+		return j
+	}
+	jobs := []*model.Job{step("a"), step("b", "a"), step("c", "a"), step("d", "b", "c")}
+	jobs[3].UsePreviousResults = true
+	if _, err := model.SortSteps(append(jobs, step("e", "f"))); err == nil {
+		t.Fatal("expected an unknown step to be rejected")
+	}
+	if _, err := model.SortSteps([]*model.Job{step("a", "b"), step("b", "a")}); err == nil {
+		t.Fatal("expected a cycle to be rejected")
+	}
+	p := model.NewPipeline("pip_1", "graph", "", nil, jobs, &now)
+	if err := storage.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+
+	exec := func(name string) {
+		j, err := storage.GetJob("job_" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Status != model.Pending || !j.IsScheduled() {
+			t.Fatalf("expected step %s to be due, got %s at %v", name, j.Status, j.RunAt)
+		}
+		j.MarkScheduled(&now)
+		if _, err := storage.UpdateJob(j.UUID, j); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.ExecJobWork(context.Background(), srv.CreateWork(j)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waiting := func(name string) {
+		if j, _ := storage.GetJob("job_" + name); j.IsScheduled() {
+			t.Fatalf("expected step %s to wait for its dependencies", name)
+		}
+	}
+
+	waiting("b")
+	exec("a")
+	waiting("d")
+	exec("c")
+	waiting("d")
+	exec("b")
+	exec("d")
+
+	expected := map[string]interface{}{"b": "job_b", "c": "job_c"}
+	if results, ok := fanIn.(map[string]interface{}); !ok || results["b"] != expected["b"] || results["c"] != expected["c"] {
+		t.Fatalf("expected the fan-in step to get %v, got %v", expected, fanIn)
+	}
+	stored, err := storage.GetPipeline(p.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != model.Completed {
+		t.Fatalf("expected the pipeline to complete, got %s", stored.Status)
+	}
+}
+
+func TestWorkService_BranchesOnStepOutcome(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	reachable := false
+	tasks.RegisterWithContext("ping", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		return map[string]interface{}{"ok": reachable}, nil
+	})
+	tasks.RegisterWithContext("connect", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		if !reachable {
+			return nil, errors.New("host unreachable")
+		}
+		return nil, nil
+	})
+	tasks.RegisterWithContext("step", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		return nil, nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+
+	now := time.Now()
+	run := func(p *model.Pipeline) {
+		if _, err := model.SortSteps(p.Jobs); err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.CreatePipeline(p); err != nil {
+			t.Fatal(err)
+		}
+		for ran := true; ran; {
+			ran = false
+			for _, j := range p.Jobs {
+				stored, _ := storage.GetJob(j.UUID)
+				if stored.Status != model.Pending || !stored.IsScheduled() {
+					continue
+				}
+				stored.MarkScheduled(&now)
+				if _, err := storage.UpdateJob(stored.UUID, stored); err != nil {
+					t.Fatal(err)
+				}
+				if err := srv.ExecJobWork(context.Background(), srv.CreateWork(stored)); err != nil {
+					t.Fatal(err)
+				}
+				ran = true
+			}
+		}
+	}
+	expect := func(p *model.Pipeline, status model.JobStatus, steps map[string]model.JobStatus) {
+		stored, _ := storage.GetPipeline(p.UUID)
+		if stored.Status != status {
+			t.Fatalf("expected pipeline %s to be %s, got %s", p.UUID, status, stored.Status)
+		}
+		for _, j := range p.Jobs {
+			stored, _ := storage.GetJob(j.UUID)
+			if stored.Status != steps[j.Name] {
+				t.Fatalf("expected step %s to be %s, got %s: %s", j.Name, steps[j.Name], stored.Status, stored.FailureReason)
+			}
+		}
+	}
+	step := func(pipelineID, name, task string) *model.Job {
+		return &model.Job{UUID: pipelineID + "_" + name, Name: name, TaskName: task, PipelineID: pipelineID, Status: model.Pending}
+	}
+
+	// Conditions on the result of a previous step.
+	ping, install, alert := step("pip_1", "ping", "ping"), step("pip_1", "install", "step"), step("pip_1", "alert", "step")
+	ping.RunAt = &now
+	install.DependsOn, install.When = []string{"ping"}, "steps.ping.result.ok == true"
+	alert.DependsOn, alert.When = []string{"ping"}, "steps.ping.result.ok == false && steps.ping.status == 'COMPLETED'"
+	conditions := model.NewPipeline("pip_1", "conditions", "", nil, []*model.Job{ping, install, alert}, &now)
+	run(conditions)
+	expect(conditions, model.Completed, map[string]model.JobStatus{"ping": model.Completed, "install": model.Skipped, "alert": model.Completed})
+
+	// Branches on the outcome of a previous step, the handled failure doesn't fail the pipeline.
+	connect, install, alert, report := step("pip_2", "connect", "connect"), step("pip_2", "install", "step"), step("pip_2", "alert", "step"), step("pip_2", "report", "step")
+	connect.RunAt = &now
+	connect.OnSuccess, connect.OnFailure = []string{"install"}, []string{"alert"}
+	report.DependsOn = []string{"install"}
+	branches := model.NewPipeline("pip_2", "branches", "", nil, []*model.Job{connect, install, alert, report}, &now)
+	run(branches)
+	expect(branches, model.Completed, map[string]model.JobStatus{
+		"connect": model.Failed, "install": model.Skipped, "alert": model.Completed, "report": model.Skipped})
+
+	// A condition on the first step skips it along with the steps waiting for it.
+	ping, report = step("pip_4", "ping", "ping"), step("pip_4", "report", "step")
+	ping.RunAt, ping.When = &now, "vars.enabled == true"
+	report.DependsOn = []string{"ping"}
+	disabled := model.NewPipeline("pip_4", "disabled", "", nil, []*model.Job{ping, report}, &now)
+	disabled.Vars = map[string]interface{}{"enabled": false}
+	run(disabled)
+	expect(disabled, model.Completed, map[string]model.JobStatus{"ping": model.Skipped, "report": model.Skipped})
+
+	bad := []*model.Job{step("pip_3", "ping", "ping"), step("pip_3", "alert", "step")}
+	bad[1].DependsOn, bad[1].When = []string{"ping"}, "steps.other.result.ok"
+	if _, err := model.SortSteps(bad); err == nil {
+		t.Fatal("expected a condition on a step it doesn't wait for to be rejected")
+	}
+	// The listed steps would run in parallel rather than one after the other.
+	unordered := []*model.Job{step("pip_5", "ping", "ping"), step("pip_5", "install", "step")}
+	unordered[1].When = "vars.install == true"
+	if _, err := model.SortSteps(unordered); err == nil {
+		t.Fatal("expected conditional steps without depends_on to be rejected")
+	}
+}
+
+func TestWorkService_RendersTaskParams(t *testing.T) {
+	storage := memory.New()
+	tasks := taskRepo.New()
+	params := make(chan interface{}, 1)
+	tasks.RegisterWithContext("lookup", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		return struct {
+			HostUUID string `json:"host_uuid"`
+		}{"hst_1"}, nil
+	})
+	tasks.RegisterWithContext("install", func(ctx context.Context, in taskRepo.TaskInput) (interface{}, error) {
+		params <- in.Params
+		return nil, nil
+	})
+	srv := New(storage, jobqueue.NewMemoryQueue(10, "text"), wakeup.New(), tasks, intime.New(), time.Second, 1, 1, nil, logrus.New())
+
+	now := time.Now()
+	lookup := &model.Job{UUID: "job_lookup", Name: "lookup", TaskName: "lookup", PipelineID: "pip_1", Status: model.Pending, RunAt: &now}
+	install := &model.Job{
+		UUID:       "job_install",
+		Name:       "install",
+		TaskName:   "install",
+		PipelineID: "pip_1",
+		DependsOn:  []string{"lookup"},
+		Status:     model.Pending,
+		TaskParams: map[string]interface{}{
+			"host":    "{{ steps.lookup.result.host_uuid }}",
+			"version": "{{ vars.version }}",
+			"note":    "run {{ run.job_id }} started at {{ run.started_at }}",
+		},
+	}
+	p := model.NewPipeline("pip_1", "install", "", nil, []*model.Job{lookup, install}, &now)
+	p.Vars = map[string]interface{}{"version": "1.2.0"}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+	runSteps := func() {
+		for _, id := range []string{lookup.UUID, install.UUID} {
+			j, _ := storage.GetJob(id)
+			j.MarkScheduled(&now)
+			if err := srv.ExecJobWork(context.Background(), srv.CreateWork(j)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	runSteps()
+
+	stored, _ := storage.GetPipeline(p.UUID)
+	expected := "run job_install started at " + stored.StartedAt.Format(time.RFC3339)
+	select {
+	case in := <-params:
+		actual, _ := in.(map[string]interface{})
+		if actual["host"] != "hst_1" || actual["version"] != "1.2.0" || actual["note"] != expected {
+			t.Fatalf("unexpected task params %v", actual)
+		}
+	default:
+		t.Fatal("expected the install step to run")
+	}
+	if j, _ := storage.GetJob(install.UUID); j.TaskParams["host"] != "{{ steps.lookup.result.host_uuid }}" {
+		t.Fatalf("expected the stored params to keep their templates, got %v", j.TaskParams)
+	}
+
+	// A template without value fails the step without retrying it.
+	stored.Vars = nil
+	if err := storage.UpdatePipeline(p.UUID, stored); err != nil {
+		t.Fatal(err)
+	}
+	j, _ := storage.GetJob(install.UUID)
+	j.Status = model.Scheduled
+	j.JobOptions = &model.JobOptions{EnableOnFailRetry: true, MaxAttempts: 3, RetryDelay: 1}
+	attempt := j.Attempt
+	if err := srv.ExecJobWork(context.Background(), srv.CreateWork(j)); err != nil {
+		t.Fatal(err)
+	}
+	if j, _ = storage.GetJob(install.UUID); j.Status != model.Failed || j.FailureReason != "task_params: {{ vars.version }} has no value" {
+		t.Fatalf("expected the step to fail on the missing variable, got %s: %s", j.Status, j.FailureReason)
+	}
+	if j.Attempt != attempt+1 {
+		t.Fatalf("expected a single attempt, got %d", j.Attempt-attempt)
+	}
+
+	// The vars of a trigger override the pipeline vars for the run it triggers only.
+	stored.Vars = map[string]interface{}{"version": "1.2.0"}
+	stored.TriggerVars = map[string]interface{}{"version": "2.0.0"}
+	if _, err := storage.RecyclePipeline(p.UUID, stored); err != nil {
+		t.Fatal(err)
+	}
+	runSteps()
+	if in := (<-params).(map[string]interface{}); in["version"] != "2.0.0" {
+		t.Fatalf("expected the trigger vars to override the pipeline vars, got %v", in)
+	}
+	stored, _ = storage.GetPipeline(p.UUID)
+	if stored.Vars["version"] != "1.2.0" {
+		t.Fatalf("expected the pipeline vars to be kept, got %v", stored.Vars)
+	}
+	if recycled, err := storage.RecyclePipeline(p.UUID, stored); err != nil || recycled.RunVars != nil {
+		t.Fatalf("expected the next recycle to drop the trigger vars, got %v, %v", recycled, err)
+	}
+
+	// The jobs of a pipeline running one after the other look up the results of the jobs before them too.
+	jobs := []*model.Job{
+		model.NewJob("job_2_lookup", "lookup", "lookup", "", "", "pip_2", "job_2_install", 0, &now, &now, false, false, nil, nil),
+		model.NewJob("job_2_install", "install", "install", "", "", "pip_2", "", 0, &now, &now, false, false, nil,
+			map[string]interface{}{"host": "{{ steps.lookup.result.host_uuid }}"}),
+	}
+	for _, j := range jobs {
+		j.Status = model.Scheduled
+	}
+	linear := model.NewPipeline("pip_2", "install", "", nil, jobs, &now)
+	if err := linear.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreatePipeline(linear); err != nil {
+		t.Fatal(err)
+	}
+	linear.MergeJobsInOne()
+	w := srv.CreateWork(linear.Jobs[0])
+	w.Type = workType(w)
+	srv.collectResults(w)
+	if err := srv.ExecPipelineWork(context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case in := <-params:
+		if actual, _ := in.(map[string]interface{}); actual["host"] != "hst_1" {
+			t.Fatalf("unexpected task params %v", actual)
+		}
+	default:
+		j, _ := storage.GetJob("job_2_install")
+		t.Fatalf("expected the install job to run, got %s: %s", j.Status, j.FailureReason)
+	}
+}
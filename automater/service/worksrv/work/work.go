@@ -11,6 +11,8 @@ type Work struct {
 	Job         *model.Job
 	Result      chan model.JobResult
 	TimeoutUnit time.Duration
+	// Ack is called once the work has been executed, if set.
+	Ack func() error
 }
 
 // NewWork initializes and returns a new Work instance.
@@ -4,11 +4,19 @@ import (
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/automater/jobqueue"
 	"github.com/NubeIO/rubix-automater/pkg/config"
+	"time"
 )
 
 func JobQueueFactory(cfg config.JobQueue, loggingFormat string) automater.JobQueue {
+	if cfg.Option == "redis" && cfg.Reliable {
+		visibilityTimeout := time.Duration(cfg.VisibilityTimeout) * time.Second
+		return jobqueue.NewReliableRedisQueue(cfg.Redis, visibilityTimeout, loggingFormat)
+	}
 	if cfg.Option == "redis" {
 		return jobqueue.NewRedisQueue(cfg.Redis, loggingFormat)
 	}
+	if cfg.Option == "memory" {
+		return jobqueue.NewMemoryQueue(cfg.MemoryJobQueue.Capacity, loggingFormat)
+	}
 	return nil
 }
@@ -3,13 +3,25 @@ package setup
 import (
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/pkg/config"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/bolt"
 	"github.com/NubeIO/rubix-automater/pkg/database/storage/db"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/memory"
+	"github.com/NubeIO/rubix-automater/pkg/database/storage/postgres"
 )
 
-func StorageFactory(cfg config.Storage) automater.Storage {
+func StorageFactory(cfg config.Storage) (automater.Storage, error) {
 	if cfg.Option == "redis" {
 		return redis.New(
-			cfg.Redis.URL, cfg.Redis.PoolSize, cfg.Redis.MinIdleConns, cfg.Redis.KeyPrefix)
+			cfg.Redis.URL, cfg.Redis.PoolSize, cfg.Redis.MinIdleConns, cfg.Redis.KeyPrefix), nil
 	}
-	return nil
+	if cfg.Option == "memory" {
+		return memory.New(), nil
+	}
+	if cfg.Option == "bolt" {
+		return bolt.New(cfg.Bolt.Path)
+	}
+	if cfg.Option == "postgres" {
+		return postgres.New(cfg.Postgres.URL, cfg.Postgres.MaxOpenConns)
+	}
+	return nil, nil
 }
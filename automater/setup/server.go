@@ -20,6 +20,9 @@ func ServerFactory(
 	pipelineService automater.PipelineService,
 	resultService automater.ResultService,
 	taskService automater.TaskService,
+	deadLetterService automater.DeadLetterService,
+	blackoutService automater.BlackoutService,
+	pauseService automater.PauseService,
 	jobQueue automater.JobQueue,
 	storage automater.Storage, loggingFormat string,
 	logger *logrus.Logger) automater.Server {
@@ -29,7 +32,7 @@ func ServerFactory(
 			Addr: ":" + cfg.HTTP.Port,
 			Handler: router.NewRouter(
 				jobService, resultService,
-				pipelineService, taskService,
+				pipelineService, taskService, deadLetterService, blackoutService, pauseService,
 				jobQueue, storage, loggingFormat),
 		}
 		httpsrv := server.NewHTTPServer(srv, logger)
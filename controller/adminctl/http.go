@@ -3,6 +3,7 @@ package admin
 import (
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/controller"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -10,13 +11,15 @@ import (
 // AdminHTTPHandler is an HTTP controller that exposes result endpoints.
 type AdminHTTPHandler struct {
 	controller.HTTPHandler
-	storage automater.Storage
+	storage      automater.Storage
+	pauseService automater.PauseService
 }
 
 // NewAdminHTTPHandler creates and returns a new ResultHTTPHandler.
-func NewAdminHTTPHandler(storage automater.Storage) *AdminHTTPHandler {
+func NewAdminHTTPHandler(storage automater.Storage, pauseService automater.PauseService) *AdminHTTPHandler {
 	return &AdminHTTPHandler{
-		storage: storage,
+		storage:      storage,
+		pauseService: pauseService,
 	}
 }
 
@@ -34,3 +37,35 @@ func (hdl *AdminHTTPHandler) WipeDB(c *gin.Context) {
 	res := &Delete{Message: "wiped db ok"}
 	c.JSON(http.StatusOK, res)
 }
+
+// Pause pauses the queue of the path, or every queue without one. The work in progress keeps running.
+func (hdl *AdminHTTPHandler) Pause(c *gin.Context) {
+	p, err := hdl.pauseService.Pause(c.Param("queue"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// Resume resumes the queue of the path, or lifts the global pause without one.
+func (hdl *AdminHTTPHandler) Resume(c *gin.Context) {
+	p, err := hdl.pauseService.Resume(c.Param("queue"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, p)
+}
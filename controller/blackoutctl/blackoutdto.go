@@ -0,0 +1,15 @@
+package blackoutctl
+
+import (
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// BlackoutBody is the data transfer object used for a blackout creation or update.
+type BlackoutBody struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Global      bool                  `json:"global"`
+	Timezone    string                `json:"timezone"`
+	Weekly      []model.WeeklyRange   `json:"weekly"`
+	Absolute    []model.AbsoluteRange `json:"absolute"`
+}
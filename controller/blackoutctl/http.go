@@ -0,0 +1,105 @@
+package blackoutctl
+
+import (
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/controller"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlackoutHTTPHandler is an HTTP controller that exposes blackout endpoints.
+type BlackoutHTTPHandler struct {
+	controller.HTTPHandler
+	blackoutService automater.BlackoutService
+}
+
+// NewBlackoutHTTPHandler creates and returns a new BlackoutHTTPHandler.
+func NewBlackoutHTTPHandler(blackoutService automater.BlackoutService) *BlackoutHTTPHandler {
+	return &BlackoutHTTPHandler{
+		blackoutService: blackoutService,
+	}
+}
+
+// Create creates a new blackout.
+func (hdl *BlackoutHTTPHandler) Create(c *gin.Context) {
+	body := BlackoutBody{}
+	if err := c.BindJSON(&body); err != nil {
+		hdl.HandleError(c, http.StatusBadRequest, err)
+		return
+	}
+	b, err := hdl.blackoutService.Create(
+		body.Name, body.Description, body.Timezone, body.Global, body.Weekly, body.Absolute)
+	if err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, b)
+}
+
+// GetBlackouts fetches all blackouts.
+func (hdl *BlackoutHTTPHandler) GetBlackouts(c *gin.Context) {
+	blackouts, err := hdl.blackoutService.GetBlackouts()
+	if err != nil {
+		hdl.HandleError(c, http.StatusInternalServerError, err)
+		return
+	}
+	res := map[string]interface{}{
+		"blackouts": blackouts,
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Get fetches a blackout.
+func (hdl *BlackoutHTTPHandler) Get(c *gin.Context) {
+	b, err := hdl.blackoutService.Get(c.Param("uuid"))
+	if err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// Update replaces the windows of a blackout.
+func (hdl *BlackoutHTTPHandler) Update(c *gin.Context) {
+	body := BlackoutBody{}
+	if err := c.BindJSON(&body); err != nil {
+		hdl.HandleError(c, http.StatusBadRequest, err)
+		return
+	}
+	b, err := hdl.blackoutService.Update(c.Param("uuid"), &model.Blackout{
+		Name:        body.Name,
+		Description: body.Description,
+		Global:      body.Global,
+		Timezone:    body.Timezone,
+		Weekly:      body.Weekly,
+		Absolute:    body.Absolute,
+	})
+	if err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// Delete deletes a blackout.
+func (hdl *BlackoutHTTPHandler) Delete(c *gin.Context) {
+	if err := hdl.blackoutService.Delete(c.Param("uuid")); err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+func (hdl *BlackoutHTTPHandler) handleError(c *gin.Context, err error) {
+	switch err.(type) {
+	case *apperrors.NotFoundErr:
+		hdl.HandleError(c, http.StatusNotFound, err)
+	case *apperrors.ResourceValidationErr:
+		hdl.HandleError(c, http.StatusBadRequest, err)
+	default:
+		hdl.HandleError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,99 @@
+package deadletterctl
+
+import (
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/controller"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadLetterHTTPHandler is an HTTP controller that exposes dead letter endpoints.
+type DeadLetterHTTPHandler struct {
+	controller.HTTPHandler
+	deadLetterService automater.DeadLetterService
+}
+
+// NewDeadLetterHTTPHandler creates and returns a new DeadLetterHTTPHandler.
+func NewDeadLetterHTTPHandler(deadLetterService automater.DeadLetterService) *DeadLetterHTTPHandler {
+	return &DeadLetterHTTPHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// GetDeadLetters fetches all dead letters.
+func (hdl *DeadLetterHTTPHandler) GetDeadLetters(c *gin.Context) {
+	deadLetters, err := hdl.deadLetterService.GetDeadLetters()
+	if err != nil {
+		hdl.HandleError(c, http.StatusInternalServerError, err)
+		return
+	}
+	res := map[string]interface{}{
+		"dead_letters": deadLetters,
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Get fetches a dead letter.
+func (hdl *DeadLetterHTTPHandler) Get(c *gin.Context) {
+	d, err := hdl.deadLetterService.Get(c.Param("uuid"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.NotFoundErr:
+			hdl.HandleError(c, http.StatusNotFound, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// Requeue pushes the job of a dead letter back to the job queue.
+func (hdl *DeadLetterHTTPHandler) Requeue(c *gin.Context) {
+	j, err := hdl.deadLetterService.Requeue(c.Param("uuid"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.NotFoundErr:
+			hdl.HandleError(c, http.StatusNotFound, err)
+			return
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		case *apperrors.FullQueueErr, *apperrors.ClosedQueueErr:
+			hdl.HandleError(c, http.StatusServiceUnavailable, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusAccepted, j)
+}
+
+// Delete deletes a dead letter.
+func (hdl *DeadLetterHTTPHandler) Delete(c *gin.Context) {
+	err := hdl.deadLetterService.Delete(c.Param("uuid"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.NotFoundErr:
+			hdl.HandleError(c, http.StatusNotFound, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// Purge deletes all dead letters.
+func (hdl *DeadLetterHTTPHandler) Purge(c *gin.Context) {
+	if err := hdl.deadLetterService.Purge(); err != nil {
+		hdl.HandleError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
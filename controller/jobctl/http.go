@@ -6,7 +6,6 @@ import (
 	"github.com/NubeIO/rubix-automater/controller"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,7 +31,7 @@ func (hdl *JobHTTPHandler) Create(c *gin.Context) {
 	c.BindJSON(&body)
 
 	j, err := hdl.jobService.Create(
-		body.Name, body.TaskName, body.SubTaskName, body.Description, body.ScheduleAt, body.Timeout, body.Disable, body.Options, body.TaskParams)
+		body.Name, body.TaskName, body.SubTaskName, body.Description, body.ScheduleAt, body.Queue, body.Timeout, body.Priority, body.Disable, body.Options, body.TaskParams)
 	if err != nil {
 		switch err.(type) {
 		case *apperrors.ResourceValidationErr:
@@ -65,6 +64,25 @@ func (hdl *JobHTTPHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, BuildResponseBodyDTO(j))
 }
 
+// Cancel cancels a job waiting to run or running.
+func (hdl *JobHTTPHandler) Cancel(c *gin.Context) {
+	j, err := hdl.jobService.Cancel(c.Param("uuid"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.NotFoundErr:
+			hdl.HandleError(c, http.StatusNotFound, err)
+			return
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, BuildResponseBodyDTO(j))
+}
+
 // GetJobs fetches all jobs, optionally filters them by status.
 func (hdl *JobHTTPHandler) GetJobs(c *gin.Context) {
 	var status string
@@ -96,17 +114,9 @@ func (hdl *JobHTTPHandler) Update(c *gin.Context) {
 
 	job := &model.Job{
 		Name:        body.Name,
-		Description: body.Name,
+		Description: body.Description,
 		Disable:     body.Disable,
-		TaskName:    "",
-		RunAt:       &time.Time{},
-		JobOptions: &model.JobOptions{
-			EnableInterval:    body.Options.EnableInterval,
-			RunOnInterval:     body.Options.RunOnInterval,
-			EnableOnFailRetry: false,
-			HowTimesToRetry:   false,
-			OnFailRetryDelay:  &time.Time{},
-		},
+		JobOptions:  body.Options,
 	}
 
 	j, err := hdl.jobService.Update(c.Param("uuid"), job)
@@ -115,6 +125,9 @@ func (hdl *JobHTTPHandler) Update(c *gin.Context) {
 		case *apperrors.NotFoundErr:
 			hdl.HandleError(c, http.StatusNotFound, err)
 			return
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
 		default:
 			hdl.HandleError(c, http.StatusInternalServerError, err)
 			return
@@ -13,9 +13,15 @@ type JobBody struct {
 	SubTaskName        string                 `json:"sub_task"`
 	ScheduleAt         string                 `json:"schedule_at"`
 	Timeout            int                    `json:"timeout"`
+	Priority           int                    `json:"priority"`
+	Queue              string                 `json:"queue"`
 	Options            *model.JobOptions      `json:"options"`
 	TaskParams         map[string]interface{} `json:"task_params"`
 	UsePreviousResults bool                   `json:"use_previous_results"`
+	DependsOn          []string               `json:"depends_on"`
+	When               string                 `json:"when"`
+	OnSuccess          []string               `json:"on_success"`
+	OnFailure          []string               `json:"on_failure"`
 }
 
 // NewRequestBodyDTO initializes and returns a new BodyDTO instance.
@@ -43,11 +43,15 @@ func (hdl *PipelineHTTPHandler) Create(c *gin.Context) {
 			TaskParams:         jobDTO.TaskParams,
 			UsePreviousResults: jobDTO.UsePreviousResults,
 			JobOptions:         jobDTO.Options,
+			DependsOn:          jobDTO.DependsOn,
+			When:               jobDTO.When,
+			OnSuccess:          jobDTO.OnSuccess,
+			OnFailure:          jobDTO.OnFailure,
 		}
 		jobs = append(jobs, j)
 	}
 
-	p, err := hdl.pipelineService.Create(body.Name, body.Description, body.ScheduleAt, body.PipelineOptions, jobs)
+	p, err := hdl.pipelineService.Create(body.Name, body.Description, body.ScheduleAt, body.Queue, body.Priority, body.PipelineOptions, jobs, body.Vars)
 	if err != nil {
 		switch err.(type) {
 		case *apperrors.ResourceValidationErr:
@@ -68,7 +72,7 @@ func (hdl *PipelineHTTPHandler) Create(c *gin.Context) {
 		// Push only the first job of the pipeline.
 		if err := hdl.jobQueue.Push(p.Jobs[0]); err != nil {
 			switch err.(type) {
-			case *apperrors.FullQueueErr:
+			case *apperrors.FullQueueErr, *apperrors.ClosedQueueErr:
 				hdl.HandleError(c, http.StatusServiceUnavailable, err)
 				return
 			default:
@@ -86,11 +90,19 @@ func (hdl *PipelineHTTPHandler) Create(c *gin.Context) {
 // RecyclePipeline updates a pipeline.
 func (hdl *PipelineHTTPHandler) RecyclePipeline(c *gin.Context) {
 	uuid := c.Param("uuid")
+	body := &RecycleBody{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(body); err != nil {
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
 	getExisting, err := hdl.pipelineService.Get(uuid) //get the existing
 	if err != nil {
 		hdl.HandleError(c, http.StatusInternalServerError, err)
 		return
 	}
+	getExisting.TriggerVars = body.Vars
 
 	resp, err := hdl.pipelineService.RecyclePipeline(c.Param("uuid"), getExisting) // update pipeline
 	if err != nil {
@@ -107,6 +119,25 @@ func (hdl *PipelineHTTPHandler) RecyclePipeline(c *gin.Context) {
 	c.JSON(http.StatusOK, BuildResponseBodyDTO(resp))
 }
 
+// Cancel cancels a pipeline waiting to run or running.
+func (hdl *PipelineHTTPHandler) Cancel(c *gin.Context) {
+	p, err := hdl.pipelineService.Cancel(c.Param("uuid"))
+	if err != nil {
+		switch err.(type) {
+		case *apperrors.NotFoundErr:
+			hdl.HandleError(c, http.StatusNotFound, err)
+			return
+		case *apperrors.ResourceValidationErr:
+			hdl.HandleError(c, http.StatusBadRequest, err)
+			return
+		default:
+			hdl.HandleError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, BuildResponseBodyDTO(p))
+}
+
 // Get fetches a pipeline.
 func (hdl *PipelineHTTPHandler) Get(c *gin.Context) {
 	j, err := hdl.pipelineService.Get(c.Param("uuid"))
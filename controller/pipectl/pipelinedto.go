@@ -10,8 +10,17 @@ type PipelineBody struct {
 	Name            string                 `json:"name"`
 	Description     string                 `json:"description"`
 	ScheduleAt      string                 `json:"schedule_at"`
+	Priority        int                    `json:"priority"`
+	Queue           string                 `json:"queue"`
 	PipelineOptions *model.PipelineOptions `json:"options"`
 	Jobs            []*jobctl.JobBody      `json:"jobs"`
+	Vars            map[string]interface{} `json:"vars"`
+}
+
+// RecycleBody is the optional data transfer object used for a pipeline recycle.
+type RecycleBody struct {
+	// Vars override the pipeline variables for the triggered run only.
+	Vars map[string]interface{} `json:"vars"`
 }
 
 // NewRequestBodyDTO initializes and returns a new BodyDTO instance.
@@ -0,0 +1,79 @@
+package schedulectl
+
+import (
+	"fmt"
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/controller"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleHTTPHandler is an HTTP controller that exposes schedule preview endpoints.
+type ScheduleHTTPHandler struct {
+	controller.HTTPHandler
+	jobService automater.JobService
+}
+
+// NewScheduleHTTPHandler creates and returns a new ScheduleHTTPHandler.
+func NewScheduleHTTPHandler(jobService automater.JobService) *ScheduleHTTPHandler {
+	return &ScheduleHTTPHandler{
+		jobService: jobService,
+	}
+}
+
+// PreviewBody is the data transfer object of a schedule preview.
+type PreviewBody struct {
+	RunAt         string `json:"run_at"`
+	RunOnInterval string `json:"run_on_interval"`
+	Cron          string `json:"cron"`
+	Timezone      string `json:"timezone"`
+	Count         int    `json:"count"`
+}
+
+// Preview lists the next run times of a schedule.
+func (hdl *ScheduleHTTPHandler) Preview(c *gin.Context) {
+	body := PreviewBody{}
+	if err := c.BindJSON(&body); err != nil {
+		hdl.HandleError(c, http.StatusBadRequest, err)
+		return
+	}
+	runs, err := hdl.jobService.PreviewSchedule(body.RunAt, body.RunOnInterval, body.Cron, body.Timezone, body.Count)
+	if err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetJobSchedule lists the next run times of a job.
+func (hdl *ScheduleHTTPHandler) GetJobSchedule(c *gin.Context) {
+	var count int
+	if value, ok := c.GetQuery("count"); ok {
+		var err error
+		count, err = strconv.Atoi(value)
+		if err != nil {
+			hdl.HandleError(c, http.StatusBadRequest, fmt.Errorf("count should be a number, %s given", value))
+			return
+		}
+	}
+	runs, err := hdl.jobService.Schedule(c.Param("uuid"), count)
+	if err != nil {
+		hdl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (hdl *ScheduleHTTPHandler) handleError(c *gin.Context, err error) {
+	switch err.(type) {
+	case *apperrors.NotFoundErr:
+		hdl.HandleError(c, http.StatusNotFound, err)
+	case *apperrors.ResourceValidationErr, *apperrors.ParseTimeErr:
+		hdl.HandleError(c, http.StatusBadRequest, err)
+	default:
+		hdl.HandleError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"github.com/NubeIO/rubix-automater/automater/model"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"runtime"
@@ -10,6 +11,8 @@ import (
 
 var redisURL = "redis://localhost:6379"
 
+var postgresURL = "postgres://localhost:5432/automater?sslmode=disable"
+
 var (
 	validTimeoutUnitOptions = map[string]time.Duration{
 		"second":      time.Second,
@@ -20,10 +23,14 @@ var (
 		"json": true,
 	}
 	validStorageOptions = map[string]bool{
-		"redis": true,
+		"redis":    true,
+		"memory":   true,
+		"bolt":     true,
+		"postgres": true,
 	}
 	validJobQueueOptions = map[string]bool{
-		"redis": true,
+		"redis":  true,
+		"memory": true,
 	}
 	validProtocolOptions = map[string]bool{
 		"http": true,
@@ -63,23 +70,57 @@ type PublishParams struct {
 }
 
 type JobQueue struct {
-	Option string `yaml:"option"`
-	Redis  Redis  `yaml:"redis"`
+	Option         string         `yaml:"option"`
+	MemoryJobQueue MemoryJobQueue `yaml:"memory_job_queue"`
+	Redis          Redis          `yaml:"redis"`
+	// Reliable redelivers the redis queue jobs that were not acknowledged within the visibility timeout.
+	Reliable bool `yaml:"reliable"`
+	// VisibilityTimeout is in seconds, it must be longer than the longest job timeout.
+	VisibilityTimeout int `yaml:"visibility_timeout"`
+}
+
+type MemoryJobQueue struct {
+	Capacity int `yaml:"capacity"`
 }
 
 type WorkerPool struct {
-	Workers       int `yaml:"workers"`
-	QueueCapacity int `yaml:"queue_capacity"`
+	Workers       int           `yaml:"workers"`
+	QueueCapacity int           `yaml:"queue_capacity"`
+	Queues        []WorkerQueue `yaml:"queues"`
+	// HeartbeatInterval is how often the leases of the held jobs are renewed and the orphaned jobs recovered.
+	HeartbeatInterval int `yaml:"heartbeat_interval"`
+}
+
+// WorkerQueue is a named queue with its own pool of workers, jobs opt in with their queue field.
+type WorkerQueue struct {
+	Name          string `yaml:"name"`
+	Workers       int    `yaml:"workers"`
+	QueueCapacity int    `yaml:"queue_capacity"`
 }
 
 type Scheduler struct {
+	// StoragePollingInterval and JobQueuePollingInterval are the safety polls of the scheduler, it's woken up
+	// whenever some work gets due or queued.
 	StoragePollingInterval  int `yaml:"storage_polling_interval"`
 	JobQueuePollingInterval int `yaml:"job_queue_polling_interval"`
+	// MisfireGraceTime is how late a recurring job can run before its run counts as missed.
+	MisfireGraceTime int `yaml:"misfire_grace_time"`
 }
 
 type Storage struct {
-	Option string `yaml:"option"`
-	Redis  Redis  `yaml:"redis"`
+	Option   string   `yaml:"option"`
+	Redis    Redis    `yaml:"redis"`
+	Bolt     Bolt     `yaml:"bolt"`
+	Postgres Postgres `yaml:"postgres"`
+}
+
+type Bolt struct {
+	Path string `yaml:"path"`
+}
+
+type Postgres struct {
+	URL          string `yaml:"url"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
 }
 
 type Redis struct {
@@ -117,7 +158,10 @@ func (cfg *Config) Load(filepath string) error {
 	if err != nil {
 		return err
 	}
-	cfg.setWorkerPoolConfig()
+	err = cfg.setWorkerPoolConfig()
+	if err != nil {
+		return err
+	}
 	err = cfg.setTimeoutUnitConfig()
 	if err != nil {
 		return err
@@ -153,10 +197,16 @@ func (cfg *Config) setJobQueueConfig() error {
 		url := redisURL
 		cfg.JobQueue.Redis.URL = url
 	}
+	if cfg.JobQueue.Reliable && cfg.JobQueue.VisibilityTimeout <= 0 {
+		cfg.JobQueue.VisibilityTimeout = 300
+	}
+	if cfg.JobQueue.Option == "memory" && cfg.JobQueue.MemoryJobQueue.Capacity <= 0 {
+		cfg.JobQueue.MemoryJobQueue.Capacity = 100
+	}
 	return nil
 }
 
-func (cfg *Config) setWorkerPoolConfig() {
+func (cfg *Config) setWorkerPoolConfig() error {
 	if cfg.WorkerPool.Workers == 0 {
 		// Defaults to number of cores.
 		cfg.WorkerPool.Workers = runtime.NumCPU()
@@ -164,6 +214,25 @@ func (cfg *Config) setWorkerPoolConfig() {
 	if cfg.WorkerPool.QueueCapacity == 0 {
 		cfg.WorkerPool.QueueCapacity = cfg.WorkerPool.Workers * 2
 	}
+	// The default queue is reserved for the jobs without a queue, served by worker_pool.workers.
+	names := map[string]bool{model.DefaultQueue: true}
+	for i := range cfg.WorkerPool.Queues {
+		q := &cfg.WorkerPool.Queues[i]
+		if q.Name == "" {
+			return fmt.Errorf("worker_pool.queues[%d] has no name", i)
+		}
+		if names[q.Name] {
+			return fmt.Errorf("%s is not a valid worker_pool queue name, it is already in use", q.Name)
+		}
+		names[q.Name] = true
+		if q.Workers <= 0 {
+			q.Workers = 1
+		}
+		if q.QueueCapacity <= 0 {
+			q.QueueCapacity = q.Workers * 2
+		}
+	}
+	return nil
 }
 
 func (cfg *Config) setTimeoutUnitConfig() error {
@@ -185,6 +254,17 @@ func (cfg *Config) setSchedulerConfig() {
 			cfg.Scheduler.JobQueuePollingInterval = 1000
 		}
 	}
+	if cfg.WorkerPool.HeartbeatInterval == 0 {
+		if cfg.TimeoutUnit == time.Second {
+			cfg.WorkerPool.HeartbeatInterval = 10
+		} else {
+			cfg.WorkerPool.HeartbeatInterval = 10000
+		}
+	}
+	if cfg.Scheduler.MisfireGraceTime == 0 {
+		// A due job waits up to a polling interval anyway.
+		cfg.Scheduler.MisfireGraceTime = cfg.Scheduler.StoragePollingInterval
+	}
 }
 
 func (cfg *Config) setLoggingFormatConfig() error {
@@ -212,5 +292,11 @@ func (cfg *Config) setStorageConfig() error {
 			cfg.Storage.Redis.MinIdleConns = 10
 		}
 	}
+	if cfg.Storage.Option == "bolt" && cfg.Storage.Bolt.Path == "" {
+		cfg.Storage.Bolt.Path = "automater.db"
+	}
+	if cfg.Storage.Option == "postgres" && cfg.Storage.Postgres.URL == "" {
+		cfg.Storage.Postgres.URL = postgresURL
+	}
 	return nil
 }
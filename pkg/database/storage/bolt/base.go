@@ -0,0 +1,99 @@
+package bolt
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"go.etcd.io/bbolt"
+)
+
+var _ automater.Storage = &Bolt{}
+
+const (
+	pipeline    = "pipeline"
+	job         = "job"
+	transaction = "transaction"
+	jobresult   = "jobresult"
+	blackout    = "blackout"
+	settings    = "settings"
+	lease       = "lease"
+)
+
+var buckets = []string{pipeline, job, transaction, jobresult, blackout, settings, lease}
+
+// Bolt represents a file-backed storage built on bbolt.
+type Bolt struct {
+	db *bbolt.DB
+}
+
+// New opens (or creates) the bbolt database file under path.
+func New(path string) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		return createBuckets(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db: db}, nil
+}
+
+func createBuckets(tx *bbolt.Tx) error {
+	for _, name := range buckets {
+		if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WipeDB wipes the db.
+func (inst *Bolt) WipeDB() error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range buckets {
+			if err := tx.DeleteBucket([]byte(name)); err != nil && err != bbolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return createBuckets(tx)
+	})
+}
+
+// CheckHealth checks if the storage is alive.
+func (inst *Bolt) CheckHealth() bool {
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+	return err == nil
+}
+
+// Close terminates any storage connections gracefully.
+func (inst *Bolt) Close() error {
+	return inst.db.Close()
+}
+
+func put(tx *bbolt.Tx, bucket, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(bucket)).Put([]byte(key), value)
+}
+
+// get decodes the value stored under key into v and reports whether it was found.
+func get(tx *bbolt.Tx, bucket, key string, v interface{}) (bool, error) {
+	value := tx.Bucket([]byte(bucket)).Get([]byte(key))
+	if value == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, v)
+}
+
+func del(tx *bbolt.Tx, bucket, key string) error {
+	return tx.Bucket([]byte(bucket)).Delete([]byte(key))
+}
@@ -0,0 +1,71 @@
+package bolt
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"go.etcd.io/bbolt"
+)
+
+// CreateBlackout adds a new blackout to the storage.
+func (inst *Bolt) CreateBlackout(b *model.Blackout) error {
+	return inst.UpdateBlackout(b.UUID, b)
+}
+
+// GetBlackout fetches a blackout from the storage.
+func (inst *Bolt) GetBlackout(uuid string) (*model.Blackout, error) {
+	var b *model.Blackout
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		found, err := get(tx, blackout, uuid, &b)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &apperrors.NotFoundErr{UUID: uuid, ResourceName: "blackout"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlackouts fetches all blackouts from the storage.
+func (inst *Bolt) GetBlackouts() ([]*model.Blackout, error) {
+	var blackouts []*model.Blackout
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(blackout)).ForEach(func(_, value []byte) error {
+			b := &model.Blackout{}
+			if err := json.Unmarshal(value, b); err != nil {
+				return err
+			}
+			blackouts = append(blackouts, b)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(blackouts, func(i, j int) bool {
+		return blackouts[i].CreatedAt.Before(*blackouts[j].CreatedAt)
+	})
+	return blackouts, nil
+}
+
+// UpdateBlackout updates a blackout to the storage.
+func (inst *Bolt) UpdateBlackout(uuid string, b *model.Blackout) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx, blackout, uuid, b)
+	})
+}
+
+// DeleteBlackout deletes a blackout from the storage.
+func (inst *Bolt) DeleteBlackout(uuid string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return del(tx, blackout, uuid)
+	})
+}
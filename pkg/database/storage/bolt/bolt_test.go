@@ -0,0 +1,103 @@
+package bolt
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+func TestBolt_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "automater.db")
+	inst, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	jobs := []*model.Job{
+		model.NewJob("job_1", "ping", "task", "", "", "pip_1", "job_2", 0, &past, &now, false, false, nil, nil),
+		model.NewJob("job_2", "install", "task", "", "", "pip_1", "", 0, &past, &now, false, false, nil, nil),
+	}
+	p := model.NewPipeline("pip_1", "pipeline", "", nil, jobs, &now)
+	if err := inst.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.CreateTransaction(jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.CreateJobResult(&model.JobResult{JobID: "job_1", Metadata: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	inst, err = New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	due, err := inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("expected 2 due jobs after reopen, got %d", len(due))
+	}
+	if _, err := inst.GetPipeline("pip_1"); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := inst.GetJobResult("job_1"); err != nil || result.Metadata != "ok" {
+		t.Fatalf("expected job result to survive reopen, got %v %v", result, err)
+	}
+	transactions, err := inst.GetTransactions(model.Undefined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || !transactions[0].IsPipeLine {
+		t.Fatalf("expected 1 pipeline transaction after reopen, got %v", transactions)
+	}
+}
+
+func TestBolt_Pause(t *testing.T) {
+	inst, err := New(filepath.Join(t.TempDir(), "automater.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	p, err := inst.GetPause()
+	if err != nil || p.Global || len(p.Queues) != 0 {
+		t.Fatalf("expected nothing paused, got %v, %v", p, err)
+	}
+
+	// The concurrent pauses don't overwrite each other.
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(queue string) {
+			defer wg.Done()
+			if _, err := inst.UpdatePause(func(p *model.Pause) {
+				p.PauseQueue(queue, &now)
+			}); err != nil {
+				t.Error(err)
+			}
+		}(fmt.Sprintf("queue_%d", i))
+	}
+	wg.Wait()
+	p, err = inst.GetPause()
+	if err != nil || len(p.Queues) != 5 {
+		t.Fatalf("expected the 5 queues paused, got %v, %v", p, err)
+	}
+
+	p, err = inst.UpdatePause(func(p *model.Pause) {
+		p.ResumeQueue("queue_0", &now)
+	})
+	if err != nil || p.IsPaused("queue_0") || !p.IsPaused("queue_1") {
+		t.Fatalf("expected queue_0 to be resumed only, got %v, %v", p, err)
+	}
+}
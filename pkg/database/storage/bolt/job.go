@@ -0,0 +1,243 @@
+package bolt
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"go.etcd.io/bbolt"
+)
+
+// CreateJob adds a new job to the storage.
+func (inst *Bolt) CreateJob(j *model.Job) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx, job, j.UUID, j)
+	})
+}
+
+// GetJob fetches a job from the storage.
+func (inst *Bolt) GetJob(uuid string) (*model.Job, error) {
+	var j *model.Job
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		j, err = getJob(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func getJob(tx *bbolt.Tx, uuid string) (*model.Job, error) {
+	var j *model.Job
+	found, err := get(tx, job, uuid, &j)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "job"}
+	}
+	return j, nil
+}
+
+func getAllJobs(tx *bbolt.Tx) ([]*model.Job, error) {
+	var jobs []*model.Job
+	err := tx.Bucket([]byte(job)).ForEach(func(_, value []byte) error {
+		j := &model.Job{}
+		if err := json.Unmarshal(value, j); err != nil {
+			return err
+		}
+		jobs = append(jobs, j)
+		return nil
+	})
+	return jobs, err
+}
+
+// GetJobs fetches all jobs from the storage, optionally filters the jobs by status.
+func (inst *Bolt) GetJobs(status model.JobStatus) ([]*model.Job, error) {
+	var jobs []*model.Job
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		all, err := getAllJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range all {
+			if status == model.Undefined || j.Status == status {
+				jobs = append(jobs, j)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(*jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// GetJobsByPipelineID fetches the jobs of the specified pipeline.
+func (inst *Bolt) GetJobsByPipelineID(pipelineID string) ([]*model.Job, error) {
+	var jobs []*model.Job
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		jobs, err = getJobsByPipelineID(tx, pipelineID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func getJobsByPipelineID(tx *bbolt.Tx, pipelineID string) ([]*model.Job, error) {
+	var p *model.Pipeline
+	found, err := get(tx, pipeline, pipelineID, &p)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		// Mimic the relational storages behavior.
+		return []*model.Job{}, nil
+	}
+	return p.Jobs, nil
+}
+
+// Recycle updates a job to the storage.
+func (inst *Bolt) Recycle(uuid string, j *model.Job) (*model.Job, error) {
+	var recycled *model.Job
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		recycled, err = recycle(tx, uuid, j)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recycled, nil
+}
+
+func recycle(tx *bbolt.Tx, uuid string, j *model.Job) (*model.Job, error) {
+	if _, err := getJob(tx, uuid); err != nil {
+		return nil, err
+	}
+	if err := automater.RecycleRunAt(j); err != nil {
+		return nil, err
+	}
+	now := ttime.New().Now()
+	j.UUID = uuid
+	j.Status = model.Pending
+	j.Attempt = 0
+	j.ScheduledAt = nil
+	j.StartedAt = nil
+	j.CreatedAt = &now
+	if err := put(tx, job, uuid, j); err != nil {
+		return nil, err
+	}
+	return getJob(tx, uuid)
+}
+
+// UpdateJob updates a job to the storage.
+func (inst *Bolt) UpdateJob(uuid string, j *model.Job) (*model.Job, error) {
+	var updated *model.Job
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		if err := put(tx, job, uuid, j); err != nil {
+			return err
+		}
+		if j.BelongsToPipeline() {
+			// Sync pipeline job.
+			var p *model.Pipeline
+			found, err := get(tx, pipeline, j.PipelineID, &p)
+			if err != nil {
+				return err
+			}
+			if found {
+				for i, pj := range p.Jobs {
+					if pj.UUID == j.UUID {
+						p.Jobs[i] = j
+					}
+				}
+				if err := put(tx, pipeline, p.UUID, p); err != nil {
+					return err
+				}
+			}
+		}
+		var err error
+		updated, err = getJob(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteJob deletes a job from the storage.
+func (inst *Bolt) DeleteJob(uuid string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		byJob, err := getTransactionsByJob(tx, uuid)
+		if err != nil {
+			return err
+		}
+		for _, t := range byJob {
+			if err := del(tx, transaction, t.UUID); err != nil {
+				return err
+			}
+		}
+		return del(tx, job, uuid)
+	})
+}
+
+// GetNextRunAt returns the earliest run_at of the pending jobs due from the given time, nil if there's none.
+func (inst *Bolt) GetNextRunAt(after time.Time) (*time.Time, error) {
+	var next *time.Time
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		all, err := getAllJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range all {
+			if j.IsScheduled() && j.Status == model.Pending && !j.RunAt.Before(after) && (next == nil || j.RunAt.Before(*next)) {
+				next = j.RunAt
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// GetDueJobs fetches all jobs scheduled to run before now and have not been scheduled yet.
+func (inst *Bolt) GetDueJobs() ([]*model.Job, error) {
+	var dueJobs []*model.Job
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		all, err := getAllJobs(tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, j := range all {
+			if j.IsScheduled() && j.RunAt.Before(now) && j.Status == model.Pending {
+				dueJobs = append(dueJobs, j)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// ORDER BY run_at ASC
+	sort.Slice(dueJobs, func(i, j int) bool {
+		return dueJobs[i].RunAt.Before(*dueJobs[j].RunAt)
+	})
+	return dueJobs, nil
+}
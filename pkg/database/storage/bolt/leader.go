@@ -0,0 +1,84 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"go.etcd.io/bbolt"
+)
+
+// leaderKey is the key of the scheduler leadership in the settings bucket.
+const leaderKey = "leader"
+
+// AcquireLeadership claims or extends the scheduler leadership for the owner until expiresAt.
+func (inst *Bolt) AcquireLeadership(owner string, expiresAt time.Time) (int64, bool, error) {
+	var token int64
+	acquired := false
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		l := &model.Leadership{}
+		if _, err := get(tx, settings, leaderKey, l); err != nil {
+			return err
+		}
+		now := time.Now()
+		if l.Owner != owner && !l.IsExpired(now) {
+			return nil
+		}
+		if l.Owner != owner || l.IsExpired(now) {
+			// The leadership changes hands.
+			l.Token++
+		}
+		l.Owner = owner
+		l.ExpiresAt = &expiresAt
+		token, acquired = l.Token, true
+		return put(tx, settings, leaderKey, l)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return token, acquired, nil
+}
+
+// ReleaseLeadership gives the scheduler leadership up if the owner holds it.
+func (inst *Bolt) ReleaseLeadership(owner string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		l := &model.Leadership{}
+		if _, err := get(tx, settings, leaderKey, l); err != nil {
+			return err
+		}
+		if l.Owner != owner {
+			return nil
+		}
+		l.ExpiresAt = nil
+		return put(tx, settings, leaderKey, l)
+	})
+}
+
+// ClaimLease claims the lease of the job for the owner until expiresAt, with the fencing token of the leadership.
+func (inst *Bolt) ClaimLease(owner, jobID string, token int64, expiresAt time.Time) (bool, error) {
+	claimed := false
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		if token > 0 {
+			l := &model.Leadership{}
+			if _, err := get(tx, settings, leaderKey, l); err != nil {
+				return err
+			}
+			if l.Token != token {
+				return nil
+			}
+		}
+		held := &model.Lease{}
+		found, err := get(tx, lease, jobID, held)
+		if err != nil {
+			return err
+		}
+		if found && held.Owner != owner && !held.IsExpired(time.Now()) {
+			return nil
+		}
+		claimed = true
+		return put(tx, lease, jobID, model.NewLease(jobID, owner, &expiresAt))
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
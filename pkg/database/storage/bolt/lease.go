@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"go.etcd.io/bbolt"
+)
+
+// RenewLeases claims or extends the leases of the jobs for the owner until expiresAt.
+func (inst *Bolt) RenewLeases(owner string, jobIDs []string, expiresAt time.Time) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		for _, id := range jobIDs {
+			if err := put(tx, lease, id, model.NewLease(id, owner, &expiresAt)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLeases fetches the leases of the jobs.
+func (inst *Bolt) GetLeases(jobIDs []string) (map[string]*model.Lease, error) {
+	leases := make(map[string]*model.Lease)
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		for _, id := range jobIDs {
+			l := &model.Lease{}
+			found, err := get(tx, lease, id, l)
+			if err != nil {
+				return err
+			}
+			if found {
+				leases[id] = l
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
+
+// ReleaseLeases deletes the leases of the jobs.
+func (inst *Bolt) ReleaseLeases(jobIDs []string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		for _, id := range jobIDs {
+			if err := del(tx, lease, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
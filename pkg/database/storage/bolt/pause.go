@@ -0,0 +1,38 @@
+package bolt
+
+import (
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"go.etcd.io/bbolt"
+)
+
+// pauseKey is the key of the paused state in the settings bucket.
+const pauseKey = "pause"
+
+// GetPause fetches the paused state of the scheduler from the storage.
+func (inst *Bolt) GetPause() (*model.Pause, error) {
+	p := &model.Pause{}
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		_, err := get(tx, settings, pauseKey, p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdatePause applies the update to the paused state of the scheduler in a single transaction.
+func (inst *Bolt) UpdatePause(update func(p *model.Pause)) (*model.Pause, error) {
+	p := &model.Pause{}
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := get(tx, settings, pauseKey, p); err != nil {
+			return err
+		}
+		update(p)
+		return put(tx, settings, pauseKey, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,151 @@
+package bolt
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+	"go.etcd.io/bbolt"
+)
+
+// CreatePipeline adds a new pipeline and of its jobs to the storage.
+func (inst *Bolt) CreatePipeline(p *model.Pipeline) error {
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		for _, j := range p.Jobs {
+			if err := put(tx, job, j.UUID, j); err != nil {
+				return err
+			}
+		}
+		return put(tx, pipeline, p.UUID, p)
+	})
+}
+
+// GetPipeline fetches a pipeline from the storage.
+func (inst *Bolt) GetPipeline(uuid string) (*model.Pipeline, error) {
+	var p *model.Pipeline
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		p, err = getPipeline(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func getPipeline(tx *bbolt.Tx, uuid string) (*model.Pipeline, error) {
+	var p *model.Pipeline
+	found, err := get(tx, pipeline, uuid, &p)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "pipeline"}
+	}
+	return p, nil
+}
+
+// GetPipelines fetches all pipelines from the storage, optionally filters the pipelines by status.
+func (inst *Bolt) GetPipelines(status model.JobStatus) ([]*model.Pipeline, error) {
+	var pipelines []*model.Pipeline
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(pipeline)).ForEach(func(_, value []byte) error {
+			p := &model.Pipeline{}
+			if err := json.Unmarshal(value, p); err != nil {
+				return err
+			}
+			if status == model.Undefined || p.Status == status {
+				pipelines = append(pipelines, p)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(pipelines, func(i, j int) bool {
+		return pipelines[i].CreatedAt.Before(*pipelines[j].CreatedAt)
+	})
+	return pipelines, nil
+}
+
+// RecyclePipeline updates a pipeline to the storage.
+func (inst *Bolt) RecyclePipeline(id string, p *model.Pipeline) (*model.Pipeline, error) {
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	var recycled *model.Pipeline
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		jobs, err := getJobsByPipelineID(tx, id) // get the existing pipeline jobs
+		if err != nil {
+			return err
+		}
+		runAts, err := automater.RecyclePipelineRunAts(p, jobs)
+		if err != nil {
+			return err
+		}
+		var recycleJobs []*model.Job
+		for i, j := range jobs {
+			j.RunAt = runAts[i]
+			recycleJob, err := recycle(tx, j.UUID, j) // recycle jobs
+			if err != nil {
+				return err
+			}
+			recycleJobs = append(recycleJobs, recycleJob)
+		}
+
+		p.Jobs = recycleJobs
+		p.Status = model.Pending
+		p.RunAt = nil
+		if len(recycleJobs) > 0 {
+			p.RunAt = recycleJobs[0].RunAt
+		}
+		p.StartedAt = nil
+		p.Duration = nil
+		p.RunVars = p.TriggerVars
+		if err := put(tx, pipeline, id, p); err != nil {
+			return err
+		}
+		recycled, err = getPipeline(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recycled, nil
+}
+
+// UpdatePipeline updates a pipeline to the storage.
+func (inst *Bolt) UpdatePipeline(uuid string, p *model.Pipeline) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx, pipeline, uuid, p)
+	})
+}
+
+// DeletePipeline deletes a pipeline and all its jobs from the storage.
+func (inst *Bolt) DeletePipeline(uuid string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		jobs, err := getAllJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			if j.PipelineID != uuid {
+				continue
+			}
+			if err := del(tx, jobresult, j.UUID); err != nil {
+				return err
+			}
+			if err := del(tx, job, j.UUID); err != nil {
+				return err
+			}
+		}
+		return del(tx, pipeline, uuid)
+	})
+}
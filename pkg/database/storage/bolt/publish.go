@@ -0,0 +1,6 @@
+package bolt
+
+// Pub is a no-op, the embedded storage has no broker to publish to.
+func (inst *Bolt) Pub(channel string, message interface{}) error {
+	return nil
+}
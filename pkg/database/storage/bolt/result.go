@@ -0,0 +1,47 @@
+package bolt
+
+import (
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"go.etcd.io/bbolt"
+)
+
+// CreateJobResult adds a new job result to the storage.
+func (inst *Bolt) CreateJobResult(result *model.JobResult) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx, jobresult, result.JobID, result)
+	})
+}
+
+// GetJobResult fetches a job result from the storage.
+func (inst *Bolt) GetJobResult(jobID string) (*model.JobResult, error) {
+	var result *model.JobResult
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		found, err := get(tx, jobresult, jobID, &result)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &apperrors.NotFoundErr{UUID: jobID, ResourceName: "job result"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateJobResult updates a job result to the storage.
+func (inst *Bolt) UpdateJobResult(jobID string, result *model.JobResult) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx, jobresult, jobID, result)
+	})
+}
+
+// DeleteJobResult deletes a job result from the storage.
+func (inst *Bolt) DeleteJobResult(jobID string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return del(tx, jobresult, jobID)
+	})
+}
@@ -0,0 +1,142 @@
+package bolt
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+	"go.etcd.io/bbolt"
+)
+
+// CreateTransaction adds a new trans result to the storage.
+func (inst *Bolt) CreateTransaction(job *model.Job) (*model.Transaction, error) {
+	id, _ := uuid.New().Make("tra")
+	var trans *model.Transaction
+	err := inst.db.Update(func(tx *bbolt.Tx) error {
+		now := ttime.New().Now()
+		isPipeLine := false
+		runAtUUID := "false"
+		if job.PipelineID != "" {
+			isPipeLine = true
+			getPipeline, err := getPipeline(tx, job.PipelineID)
+			if err != nil {
+				return err
+			}
+			runAtUUID = getPipeline.RunAtUUID
+		}
+		trans = &model.Transaction{
+			UUID:          id,
+			PipelineID:    job.PipelineID,
+			JobID:         job.UUID,
+			TaskType:      job.TaskName,
+			SubTaskType:   job.SubTaskName,
+			IsPipeLine:    isPipeLine,
+			RunAtUUID:     runAtUUID,
+			Status:        job.Status,
+			Attempt:       job.Attempt,
+			FailureReason: job.FailureReason,
+			StartedAt:     job.StartedAt,
+			CreatedAt:     &now,
+			CompletedAt:   job.CompletedAt,
+			Duration:      job.Duration,
+		}
+		return put(tx, transaction, id, trans)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inst.GetTransaction(id)
+}
+
+// DeleteTransaction deletes a trans from the storage.
+func (inst *Bolt) DeleteTransaction(uuid string) error {
+	return inst.db.Update(func(tx *bbolt.Tx) error {
+		return del(tx, transaction, uuid)
+	})
+}
+
+// GetTransactions fetches all trans from the storage, optionally filters the jobs by status.
+func (inst *Bolt) GetTransactions(status model.JobStatus) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		transactions, err = getTransactions(tx, status)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func getTransactions(tx *bbolt.Tx, status model.JobStatus) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	err := tx.Bucket([]byte(transaction)).ForEach(func(_, value []byte) error {
+		t := &model.Transaction{}
+		if err := json.Unmarshal(value, t); err != nil {
+			return err
+		}
+		if status == model.Undefined || t.Status == status {
+			transactions = append(transactions, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(*transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// GetTransactionsByJob fetches all trans from the storage by job
+func (inst *Bolt) GetTransactionsByJob(jobId string) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		transactions, err = getTransactionsByJob(tx, jobId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func getTransactionsByJob(tx *bbolt.Tx, jobId string) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	all, err := getTransactions(tx, model.Undefined)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		if t.JobID == jobId {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions, nil
+}
+
+// GetTransaction fetches a trans from the storage.
+func (inst *Bolt) GetTransaction(uuid string) (*model.Transaction, error) {
+	var t *model.Transaction
+	err := inst.db.View(func(tx *bbolt.Tx) error {
+		found, err := get(tx, transaction, uuid, &t)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return &apperrors.NotFoundErr{UUID: uuid, ResourceName: "transactionctl"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
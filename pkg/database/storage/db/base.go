@@ -7,6 +7,7 @@ import (
 	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/pkg/pubsub"
 	rs "github.com/NubeIO/rubix-automater/pkg/redis"
+	"sync"
 )
 
 var _ automater.Storage = &Redis{}
@@ -16,6 +17,9 @@ var ctx = context.Background()
 type Redis struct {
 	*rs.Client
 	pub libredis.Client
+
+	indexMu sync.Mutex
+	indexed bool
 }
 
 // New returns a redis autocli.
@@ -35,7 +39,8 @@ func (inst *Redis) WipeDB() error {
 	if err != nil {
 		return err
 	}
-	return nil
+	// An empty db has nothing to index.
+	return inst.Set(ctx, inst.GetRedisPrefixedKey("index-version"), indexVersion, 0).Err()
 }
 
 const (
@@ -43,6 +48,10 @@ const (
 	job         = "job"
 	transaction = "transaction"
 	jobresult   = "jobresult"
+	blackout    = "blackout"
+	pause       = "pause"
+	lease       = "lease"
+	leader      = "leader"
 )
 
 func (inst *Redis) getRedisKeyForPipeline(id string) string {
@@ -60,3 +69,11 @@ func (inst *Redis) getRedisKeyForTransaction(id string) string {
 func (inst *Redis) getRedisKeyForJobResult(id string) string {
 	return inst.GetRedisPrefixedKey(fmt.Sprintf("%s:%s", jobresult, id))
 }
+
+func (inst *Redis) getRedisKeyForLease(jobID string) string {
+	return inst.GetRedisPrefixedKey(fmt.Sprintf("%s:%s", lease, jobID))
+}
+
+func (inst *Redis) getRedisKeyForBlackout(id string) string {
+	return inst.GetRedisPrefixedKey(fmt.Sprintf("%s:%s", blackout, id))
+}
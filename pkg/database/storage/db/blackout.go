@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/go-redis/redis/v8"
+)
+
+// CreateBlackout adds a new blackout to the storage.
+func (inst *Redis) CreateBlackout(b *model.Blackout) error {
+	return inst.UpdateBlackout(b.UUID, b)
+}
+
+// GetBlackout fetches a blackout from the storage.
+func (inst *Redis) GetBlackout(uuid string) (*model.Blackout, error) {
+	val, err := inst.Get(ctx, inst.getRedisKeyForBlackout(uuid)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "blackout"}
+		}
+		return nil, err
+	}
+	var b *model.Blackout
+	if err := json.Unmarshal(val, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlackouts fetches all blackouts from the storage.
+func (inst *Redis) GetBlackouts() ([]*model.Blackout, error) {
+	var keys []string
+	iter := inst.Scan(ctx, 0, inst.GetRedisPrefixedKey(fmt.Sprintf("%s:*", blackout)), 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	var blackouts []*model.Blackout
+	for _, key := range keys {
+		value, err := inst.Get(ctx, key).Bytes()
+		if err != nil {
+			return nil, err
+		}
+		b := &model.Blackout{}
+		if err := json.Unmarshal(value, b); err != nil {
+			return nil, err
+		}
+		blackouts = append(blackouts, b)
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(blackouts, func(i, j int) bool {
+		return blackouts[i].CreatedAt.Before(*blackouts[j].CreatedAt)
+	})
+	return blackouts, nil
+}
+
+// UpdateBlackout updates a blackout to the storage.
+func (inst *Redis) UpdateBlackout(uuid string, b *model.Blackout) error {
+	value, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return inst.Set(ctx, inst.getRedisKeyForBlackout(uuid), value, 0).Err()
+}
+
+// DeleteBlackout deletes a blackout from the storage.
+func (inst *Redis) DeleteBlackout(uuid string) error {
+	return inst.Del(ctx, inst.getRedisKeyForBlackout(uuid)).Err()
+}
@@ -0,0 +1,248 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/go-redis/redis/v8"
+)
+
+/*
+Secondary indexes, kept in the same MULTI/EXEC as the resource they index
+
+- job-index:due                 ZSET of PENDING job uuids scored by RunAt (unix ms)
+- job-index:all                 SET of every job uuid
+- job-index:status:<status>     SET of job uuids per status
+- job-index:pipeline:<uuid>     SET of job uuids per pipeline
+- transaction-index:all         SET of every transaction uuid
+- transaction-index:status:<s>  SET of transaction uuids per status
+- transaction-index:job:<uuid>  SET of transaction uuids per job
+*/
+
+// indexVersion is bumped whenever the index layout changes, so it gets rebuilt on startup.
+const indexVersion = "1"
+
+func (inst *Redis) jobIndexKey(suffix string) string {
+	return inst.GetRedisPrefixedKey(fmt.Sprintf("%s-index:%s", job, suffix))
+}
+
+func (inst *Redis) transactionIndexKey(suffix string) string {
+	return inst.GetRedisPrefixedKey(fmt.Sprintf("%s-index:%s", transaction, suffix))
+}
+
+func (inst *Redis) jobStatusIndexKey(status model.JobStatus) string {
+	return inst.jobIndexKey(fmt.Sprintf("status:%s", status))
+}
+
+func (inst *Redis) jobPipelineIndexKey(pipelineID string) string {
+	return inst.jobIndexKey(fmt.Sprintf("pipeline:%s", pipelineID))
+}
+
+func (inst *Redis) transactionStatusIndexKey(status model.JobStatus) string {
+	return inst.transactionIndexKey(fmt.Sprintf("status:%s", status))
+}
+
+func (inst *Redis) transactionJobIndexKey(jobID string) string {
+	return inst.transactionIndexKey(fmt.Sprintf("job:%s", jobID))
+}
+
+func runAtScore(j *model.Job) float64 {
+	return float64(j.RunAt.UnixMilli())
+}
+
+// saveJob writes the job and updates its indexes atomically, old is the currently stored job if any.
+func (inst *Redis) saveJob(old, j *model.Job) error {
+	value, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	_, err = inst.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, inst.getRedisKeyForJob(j.UUID), value, 0)
+		inst.indexJob(pipe, old, j)
+		return nil
+	})
+	return err
+}
+
+func (inst *Redis) indexJob(pipe redis.Pipeliner, old, j *model.Job) {
+	if old != nil && old.Status != j.Status {
+		pipe.SRem(ctx, inst.jobStatusIndexKey(old.Status), j.UUID)
+	}
+	pipe.SAdd(ctx, inst.jobIndexKey("all"), j.UUID)
+	pipe.SAdd(ctx, inst.jobStatusIndexKey(j.Status), j.UUID)
+	if j.Status == model.Pending && j.IsScheduled() {
+		pipe.ZAdd(ctx, inst.jobIndexKey("due"), &redis.Z{Score: runAtScore(j), Member: j.UUID})
+	} else {
+		pipe.ZRem(ctx, inst.jobIndexKey("due"), j.UUID)
+	}
+	if j.BelongsToPipeline() {
+		pipe.SAdd(ctx, inst.jobPipelineIndexKey(j.PipelineID), j.UUID)
+	}
+}
+
+func (inst *Redis) unindexJob(pipe redis.Pipeliner, j *model.Job) {
+	pipe.SRem(ctx, inst.jobIndexKey("all"), j.UUID)
+	pipe.SRem(ctx, inst.jobStatusIndexKey(j.Status), j.UUID)
+	pipe.ZRem(ctx, inst.jobIndexKey("due"), j.UUID)
+	if j.BelongsToPipeline() {
+		pipe.SRem(ctx, inst.jobPipelineIndexKey(j.PipelineID), j.UUID)
+	}
+	pipe.Del(ctx, inst.transactionJobIndexKey(j.UUID))
+}
+
+func (inst *Redis) indexTransaction(pipe redis.Pipeliner, t *model.Transaction) {
+	pipe.SAdd(ctx, inst.transactionIndexKey("all"), t.UUID)
+	pipe.SAdd(ctx, inst.transactionStatusIndexKey(t.Status), t.UUID)
+	pipe.SAdd(ctx, inst.transactionJobIndexKey(t.JobID), t.UUID)
+}
+
+func (inst *Redis) unindexTransaction(pipe redis.Pipeliner, t *model.Transaction) {
+	pipe.SRem(ctx, inst.transactionIndexKey("all"), t.UUID)
+	pipe.SRem(ctx, inst.transactionStatusIndexKey(t.Status), t.UUID)
+	pipe.SRem(ctx, inst.transactionJobIndexKey(t.JobID), t.UUID)
+}
+
+// getJobsByUUIDs fetches the jobs with a single MGET, skipping index entries whose job is gone.
+func (inst *Redis) getJobsByUUIDs(uuids []string) ([]*model.Job, error) {
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(uuids))
+	for i, uuid := range uuids {
+		keys[i] = inst.getRedisKeyForJob(uuid)
+	}
+	values, err := inst.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.Job
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		j := &model.Job{}
+		if err := json.Unmarshal([]byte(s), j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// getTransactionsByUUIDs fetches the transactions with a single MGET, skipping stale index entries.
+func (inst *Redis) getTransactionsByUUIDs(uuids []string) ([]*model.Transaction, error) {
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(uuids))
+	for i, uuid := range uuids {
+		keys[i] = inst.getRedisKeyForTransaction(uuid)
+	}
+	values, err := inst.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var transactions []*model.Transaction
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		t := &model.Transaction{}
+		if err := json.Unmarshal([]byte(s), t); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// ensureIndexes rebuilds the indexes once per process if they were built by an older layout
+// (or not at all, e.g. data written before the indexes existed).
+func (inst *Redis) ensureIndexes() error {
+	inst.indexMu.Lock()
+	defer inst.indexMu.Unlock()
+	if inst.indexed {
+		return nil
+	}
+	versionKey := inst.GetRedisPrefixedKey("index-version")
+	version, err := inst.Get(ctx, versionKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if version != indexVersion {
+		if err := inst.rebuildIndexes(); err != nil {
+			return err
+		}
+		if err := inst.Set(ctx, versionKey, indexVersion, 0).Err(); err != nil {
+			return err
+		}
+	}
+	inst.indexed = true
+	return nil
+}
+
+func (inst *Redis) scanKeys(pattern string) ([]string, error) {
+	var keys []string
+	iter := inst.Scan(ctx, 0, inst.GetRedisPrefixedKey(pattern), 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+func (inst *Redis) rebuildIndexes() error {
+	for _, pattern := range []string{"job-index:*", "transaction-index:*"} {
+		stale, err := inst.scanKeys(pattern)
+		if err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			if err := inst.Del(ctx, stale...).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	jobKeys, err := inst.scanKeys("job:*")
+	if err != nil {
+		return err
+	}
+	transactionKeys, err := inst.scanKeys(fmt.Sprintf("%s:*", transaction))
+	if err != nil {
+		return err
+	}
+	_, err = inst.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range jobKeys {
+			value, err := inst.Get(ctx, key).Bytes()
+			if err != nil {
+				if err == redis.Nil {
+					continue
+				}
+				return err
+			}
+			j := &model.Job{}
+			if err := json.Unmarshal(value, j); err != nil {
+				return err
+			}
+			inst.indexJob(pipe, nil, j)
+		}
+		for _, key := range transactionKeys {
+			value, err := inst.Get(ctx, key).Bytes()
+			if err != nil {
+				if err == redis.Nil {
+					continue
+				}
+				return err
+			}
+			t := &model.Transaction{}
+			if err := json.Unmarshal(value, t); err != nil {
+				return err
+			}
+			inst.indexTransaction(pipe, t)
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	rs "github.com/NubeIO/rubix-automater/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	return &Redis{Client: rs.New("redis://"+mr.Addr(), 2, 1, "automater", nil)}, mr
+}
+
+func newTestJob(uuid, pipelineID string, runAt time.Time) *model.Job {
+	createdAt := time.Now()
+	return model.NewJob(uuid, uuid, "task", "", "", pipelineID, "", 0,
+		&runAt, &createdAt, false, false, nil, nil)
+}
+
+func TestRedis_DueJobsIndex(t *testing.T) {
+	inst, _ := newTestRedis(t)
+	now := time.Now()
+	if err := inst.CreateJob(newTestJob("job_2", "", now.Add(-time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.CreateJob(newTestJob("job_1", "", now.Add(-time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.CreateJob(newTestJob("job_3", "", now.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	due, err := inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[0].UUID != "job_1" || due[1].UUID != "job_2" {
+		t.Fatalf("expected job_1 and job_2 due in run_at order, got %v", due)
+	}
+
+	j := due[0]
+	j.Status = model.Scheduled
+	if _, err := inst.UpdateJob(j.UUID, j); err != nil {
+		t.Fatal(err)
+	}
+	due, err = inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].UUID != "job_2" {
+		t.Fatalf("expected only job_2 due after scheduling job_1, got %v", due)
+	}
+
+	scheduled, err := inst.GetJobs(model.Scheduled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 1 || scheduled[0].UUID != "job_1" {
+		t.Fatalf("expected job_1 to be indexed as scheduled, got %v", scheduled)
+	}
+	pending, err := inst.GetJobs(model.Pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending jobs, got %d", len(pending))
+	}
+
+	if err := inst.DeleteJob("job_2"); err != nil {
+		t.Fatal(err)
+	}
+	due, err = inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("expected no due jobs after delete, got %v", due)
+	}
+	all, err := inst.GetJobs(model.Undefined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(all))
+	}
+}
+
+func TestRedis_PipelineJobsIndex(t *testing.T) {
+	inst, _ := newTestRedis(t)
+	now := time.Now()
+	j := newTestJob("job_1", "pip_1", now)
+	j.Status = model.Scheduled
+	p := model.NewPipeline("pip_1", "pipeline", "", nil, []*model.Job{j}, &now)
+	if err := inst.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+	if scheduled, _ := inst.GetJobs(model.Scheduled); len(scheduled) != 1 {
+		t.Fatalf("expected job_1 to be indexed as scheduled, got %v", scheduled)
+	}
+
+	// Storing the pipeline again moves its jobs between the status indexes.
+	j.Status = model.Pending
+	if err := inst.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+	scheduled, err := inst.GetJobs(model.Scheduled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 0 {
+		t.Fatalf("expected no scheduled jobs, got %v", scheduled)
+	}
+	due, err := inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].UUID != "job_1" {
+		t.Fatalf("expected job_1 due, got %v", due)
+	}
+}
+
+func TestRedis_NextRunAt(t *testing.T) {
+	inst, _ := newTestRedis(t)
+	now := time.Now()
+	next, err := inst.GetNextRunAt(now)
+	if err != nil || next != nil {
+		t.Fatalf("expected no next run, got %v, %v", next, err)
+	}
+	for i, runAt := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour), now.Add(time.Minute)} {
+		if err := inst.CreateJob(newTestJob(fmt.Sprintf("job_%d", i), "", runAt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next, err = inst.GetNextRunAt(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil || next.UnixMilli() != now.Add(time.Minute).UnixMilli() {
+		t.Fatalf("expected the next run in a minute, got %v", next)
+	}
+}
+
+func TestRedis_RebuildIndexes(t *testing.T) {
+	inst, mr := newTestRedis(t)
+	if err := inst.CreateJob(newTestJob("job_1", "", time.Now().Add(-time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate data written before the indexes existed.
+	for _, key := range mr.Keys() {
+		if key != inst.getRedisKeyForJob("job_1") {
+			mr.Del(key)
+		}
+	}
+	inst.indexed = false
+
+	due, err := inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].UUID != "job_1" {
+		t.Fatalf("expected job_1 to be due after rebuild, got %v", due)
+	}
+}
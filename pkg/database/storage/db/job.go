@@ -2,29 +2,23 @@ package redis
 
 import (
 	"encoding/json"
-	"fmt"
+	"github.com/NubeIO/rubix-automater/automater"
 	"github.com/NubeIO/rubix-automater/automater/model"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
 	"github.com/go-redis/redis/v8"
-	"github.com/sirupsen/logrus"
-	"math"
 	"sort"
+	"strconv"
 	"time"
 )
 
 // CreateJob adds a new job to the storage.
 func (inst *Redis) CreateJob(j *model.Job) error {
-	key := inst.getRedisKeyForJob(j.UUID)
-	value, err := json.Marshal(j)
+	old, err := inst.getJobIfExists(j.UUID)
 	if err != nil {
 		return err
 	}
-	err = inst.Set(ctx, key, value, 0).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+	return inst.saveJob(old, j)
 }
 
 // GetJob fetches a job from the storage.
@@ -45,30 +39,34 @@ func (inst *Redis) GetJob(uuid string) (*model.Job, error) {
 	return j, nil
 }
 
+// getJobIfExists fetches a job from the storage, returning nil if it doesn't exist.
+func (inst *Redis) getJobIfExists(uuid string) (*model.Job, error) {
+	j, err := inst.GetJob(uuid)
+	if err != nil {
+		if _, ok := err.(*apperrors.NotFoundErr); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return j, nil
+}
+
 // GetJobs fetches all jobs from the storage, optionally filters the jobs by status.
 func (inst *Redis) GetJobs(status model.JobStatus) ([]*model.Job, error) {
-	var keys []string
-	key := inst.GetRedisPrefixedKey("job:*")
-	iter := inst.Scan(ctx, 0, key, 0).Iterator()
-	for iter.Next(ctx) {
-		keys = append(keys, iter.Val())
+	if err := inst.ensureIndexes(); err != nil {
+		return nil, err
 	}
-	if err := iter.Err(); err != nil {
+	key := inst.jobIndexKey("all")
+	if status != model.Undefined {
+		key = inst.jobStatusIndexKey(status)
+	}
+	uuids, err := inst.SMembers(ctx, key).Result()
+	if err != nil {
 		return nil, err
 	}
-	var jobs []*model.Job
-	for _, key := range keys {
-		value, err := inst.Get(ctx, key).Bytes()
-		if err != nil {
-			return nil, err
-		}
-		j := &model.Job{}
-		if err := json.Unmarshal(value, j); err != nil {
-			return nil, err
-		}
-		if status == model.Undefined || j.Status == status {
-			jobs = append(jobs, j)
-		}
+	jobs, err := inst.getJobsByUUIDs(uuids)
+	if err != nil {
+		return nil, err
 	}
 
 	// ORDER BY created_at ASC
@@ -100,29 +98,23 @@ func (inst *Redis) GetJobsByPipelineID(pipelineID string) ([]*model.Job, error)
 
 // Recycle updates a job to the storage.
 func (inst *Redis) Recycle(uuid string, j *model.Job) (*model.Job, error) {
-	var err error
-	_, err = inst.GetJob(uuid)
+	old, err := inst.GetJob(uuid)
 	if err != nil {
 		return nil, err
 	}
+	if err := automater.RecycleRunAt(j); err != nil {
+		return nil, err
+	}
 	now := ttime.New().Now()
 	j.UUID = uuid
 	j.Status = model.Pending
+	j.Attempt = 0
 	j.ScheduledAt = nil
 	j.StartedAt = nil
 	j.CreatedAt = &now
-	err = inst.Watch(ctx, func(tx *redis.Tx) error {
-		key := inst.getRedisKeyForJob(uuid)
-		value, err := json.Marshal(j)
-		if err != nil {
-			return err
-		}
-		err = inst.Set(ctx, key, value, 0).Err()
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+	if err := inst.saveJob(old, j); err != nil {
+		return nil, err
+	}
 	job, err := inst.GetJob(uuid)
 	if err != nil {
 		return nil, err
@@ -133,14 +125,11 @@ func (inst *Redis) Recycle(uuid string, j *model.Job) (*model.Job, error) {
 // UpdateJob updates a job to the storage.
 func (inst *Redis) UpdateJob(uuid string, j *model.Job) (*model.Job, error) {
 	err := inst.Watch(ctx, func(tx *redis.Tx) error {
-		key := inst.getRedisKeyForJob(uuid)
-		value, err := json.Marshal(j)
+		old, err := inst.getJobIfExists(uuid)
 		if err != nil {
 			return err
 		}
-
-		err = inst.Set(ctx, key, value, 0).Err()
-		if err != nil {
+		if err := inst.saveJob(old, j); err != nil {
 			return err
 		}
 		if j.BelongsToPipeline() {
@@ -177,7 +166,10 @@ func (inst *Redis) UpdateJob(uuid string, j *model.Job) (*model.Job, error) {
 
 // DeleteJob deletes a job from the storage.
 func (inst *Redis) DeleteJob(uuid string) error {
-	key := inst.getRedisKeyForJob(uuid)
+	j, err := inst.getJobIfExists(uuid)
+	if err != nil {
+		return err
+	}
 	byJob, err := inst.GetTransactionsByJob(uuid)
 	if err != nil {
 		return err
@@ -188,64 +180,61 @@ func (inst *Redis) DeleteJob(uuid string) error {
 			return err
 		}
 	}
-	_, err = inst.Del(ctx, key).Result()
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err = inst.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, inst.getRedisKeyForJob(uuid))
+		if j != nil {
+			inst.unindexJob(pipe, j)
+		}
+		return nil
+	})
+	return err
 }
 
-func timeDif(t1, t2 time.Time) string {
-	hs := t1.Sub(t2).Hours()
-	hs, mf := math.Modf(hs)
-	ms := mf * 60
-	ms, sf := math.Modf(ms)
-	ss := sf * 60
-	return fmt.Sprintf("%f hours %f minutes %f seconds", hs, ms, ss)
-
+// GetNextRunAt returns the earliest run_at of the pending jobs due from the given time, nil if there's none.
+func (inst *Redis) GetNextRunAt(after time.Time) (*time.Time, error) {
+	if err := inst.ensureIndexes(); err != nil {
+		return nil, err
+	}
+	// The index has millisecond precision, the jobs of the same millisecond are picked up by the next pass.
+	due, err := inst.ZRangeByScoreWithScores(ctx, inst.jobIndexKey("due"), &redis.ZRangeBy{
+		Min:   strconv.FormatInt(after.UnixMilli(), 10),
+		Max:   "+inf",
+		Count: 1,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	runAt := time.UnixMilli(int64(due[0].Score))
+	return &runAt, nil
 }
 
 // GetDueJobs fetches all jobs scheduled to run before now and have not been scheduled yet.
 func (inst *Redis) GetDueJobs() ([]*model.Job, error) {
-	var keys []string
-	key := inst.GetRedisPrefixedKey("job:*")
-	iter := inst.Scan(ctx, 0, key, 0).Iterator()
-	for iter.Next(ctx) {
-		keys = append(keys, iter.Val())
+	if err := inst.ensureIndexes(); err != nil {
+		return nil, err
 	}
-	if err := iter.Err(); err != nil {
+	now := time.Now()
+	// ORDER BY run_at ASC
+	uuids, err := inst.ZRangeByScore(ctx, inst.jobIndexKey("due"), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := inst.getJobsByUUIDs(uuids)
+	if err != nil {
 		return nil, err
 	}
-
 	var dueJobs []*model.Job
-	var pendingJobs []*model.Job
-	for _, key := range keys {
-
-		value, err := inst.Get(ctx, key).Bytes()
-		if err != nil {
-			return nil, err
-		}
-		j := &model.Job{}
-		if err := json.Unmarshal(value, j); err != nil {
-			return nil, err
-		}
-
-		if j.IsScheduled() {
-			if j.RunAt.Before(time.Now()) && j.Status == model.Pending {
-				dueJobs = append(dueJobs, j)
-			}
-			if j.RunAt.After(time.Now()) && j.Status == model.Pending {
-				pendingJobs = append(pendingJobs, j)
-				logrus.Infof("name:%s task:%s, will run at:%s", j.Name, j.TaskName, timeDif(*j.RunAt, time.Now()))
-
-			}
-
+	for _, j := range jobs {
+		// The index has millisecond precision, so double check against the job itself.
+		if j.IsScheduled() && j.RunAt.Before(now) && j.Status == model.Pending {
+			dueJobs = append(dueJobs, j)
 		}
 	}
-	logrus.Infof("get due jobs count:%d due", len(pendingJobs))
-	// ORDER BY run_at ASC
-	sort.Slice(dueJobs, func(i, j int) bool {
-		return dueJobs[i].RunAt.Before(*dueJobs[j].RunAt)
-	})
 	return dueJobs, nil
 }
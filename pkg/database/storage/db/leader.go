@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/go-redis/redis/v8"
+)
+
+/*
+The scheduler leadership is the leader key holding the owner until its TTL, leader-token is the fencing token
+bumped every time the leadership changes hands. The job leases are claimed with the token of the leadership, so a
+stale leader whose token got bumped can't claim jobs anymore.
+*/
+
+// acquireLeadershipScript claims or extends the leader key KEYS[1] for the owner ARGV[1] during ARGV[2] ms, it
+// returns the fencing token KEYS[2] of the leadership, bumped when the leadership changes hands, or -1.
+var acquireLeadershipScript = redis.NewScript(`
+local owner = redis.call('GET', KEYS[1])
+if owner and owner ~= ARGV[1] then
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+if owner then
+	local token = redis.call('GET', KEYS[2])
+	if token then
+		return tonumber(token)
+	end
+end
+return redis.call('INCR', KEYS[2])
+`)
+
+// releaseLeadershipScript deletes the leader key KEYS[1] if the owner ARGV[1] holds it.
+var releaseLeadershipScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// claimLeaseScript sets the lease KEYS[1] to ARGV[1] during ARGV[2] ms, unless the lease is held by another owner
+// than ARGV[3] or the fencing token ARGV[4] isn't the leadership token KEYS[2], a zero token skips the check.
+var claimLeaseScript = redis.NewScript(`
+local token = tonumber(ARGV[4])
+if token > 0 and tonumber(redis.call('GET', KEYS[2]) or '0') ~= token then
+	return 0
+end
+local lease = redis.call('GET', KEYS[1])
+if lease then
+	local ok, l = pcall(cjson.decode, lease)
+	if not ok or type(l) ~= 'table' or l['owner'] ~= ARGV[3] then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+func (inst *Redis) leaderKeys() []string {
+	return []string{inst.GetRedisPrefixedKey(leader), inst.GetRedisPrefixedKey(leader + "-token")}
+}
+
+// AcquireLeadership claims or extends the scheduler leadership for the owner until expiresAt.
+func (inst *Redis) AcquireLeadership(owner string, expiresAt time.Time) (int64, bool, error) {
+	ttl := time.Until(expiresAt).Milliseconds()
+	if ttl <= 0 {
+		return 0, false, nil
+	}
+	token, err := acquireLeadershipScript.Run(ctx, inst.Client, inst.leaderKeys(), owner, ttl).Int64()
+	if err != nil {
+		return 0, false, err
+	}
+	if token < 0 {
+		return 0, false, nil
+	}
+	return token, true, nil
+}
+
+// ReleaseLeadership gives the scheduler leadership up if the owner holds it.
+func (inst *Redis) ReleaseLeadership(owner string) error {
+	return releaseLeadershipScript.Run(ctx, inst.Client, inst.leaderKeys()[:1], owner).Err()
+}
+
+// ClaimLease claims the lease of the job for the owner until expiresAt, with the fencing token of the leadership.
+func (inst *Redis) ClaimLease(owner, jobID string, token int64, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt).Milliseconds()
+	if ttl <= 0 {
+		return false, nil
+	}
+	value, err := json.Marshal(model.NewLease(jobID, owner, &expiresAt))
+	if err != nil {
+		return false, err
+	}
+	keys := []string{inst.getRedisKeyForLease(jobID), inst.leaderKeys()[1]}
+	claimed, err := claimLeaseScript.Run(ctx, inst.Client, keys, value, ttl, owner, token).Int()
+	if err != nil {
+		return false, err
+	}
+	return claimed == 1, nil
+}
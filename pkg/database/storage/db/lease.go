@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/go-redis/redis/v8"
+)
+
+// RenewLeases claims or extends the leases of the jobs for the owner until expiresAt, the keys expire along.
+func (inst *Redis) RenewLeases(owner string, jobIDs []string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if len(jobIDs) == 0 || ttl <= 0 {
+		return nil
+	}
+	_, err := inst.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range jobIDs {
+			value, err := json.Marshal(model.NewLease(id, owner, &expiresAt))
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, inst.getRedisKeyForLease(id), value, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetLeases fetches the leases of the jobs with a single MGET.
+func (inst *Redis) GetLeases(jobIDs []string) (map[string]*model.Lease, error) {
+	leases := make(map[string]*model.Lease)
+	if len(jobIDs) == 0 {
+		return leases, nil
+	}
+	keys := make([]string, len(jobIDs))
+	for i, id := range jobIDs {
+		keys[i] = inst.getRedisKeyForLease(id)
+	}
+	values, err := inst.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		l := &model.Lease{}
+		if err := json.Unmarshal([]byte(s), l); err != nil {
+			return nil, err
+		}
+		leases[l.JobID] = l
+	}
+	return leases, nil
+}
+
+// ReleaseLeases deletes the leases of the jobs.
+func (inst *Redis) ReleaseLeases(jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(jobIDs))
+	for i, id := range jobIDs {
+		keys[i] = inst.getRedisKeyForLease(id)
+	}
+	return inst.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedis_Leases(t *testing.T) {
+	inst, mr := newTestRedis(t)
+	expiresAt := time.Now().Add(time.Minute)
+	if err := inst.RenewLeases("ins_1", []string{"job_1", "job_2"}, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.ReleaseLeases([]string{"job_2"}); err != nil {
+		t.Fatal(err)
+	}
+	leases, err := inst.GetLeases([]string{"job_1", "job_2", "job_3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != 1 || leases["job_1"].Owner != "ins_1" || leases["job_1"].IsExpired(time.Now()) {
+		t.Fatalf("expected the lease of job_1 only, got %v", leases)
+	}
+
+	// The lease keys expire along with the leases.
+	mr.FastForward(2 * time.Minute)
+	leases, err = inst.GetLeases([]string{"job_1"})
+	if err != nil || len(leases) != 0 {
+		t.Fatalf("expected the lease to expire, got %v, %v", leases, err)
+	}
+}
+
+func TestRedis_Leadership(t *testing.T) {
+	inst, mr := newTestRedis(t)
+	expiresAt := time.Now().Add(time.Minute)
+	token, acquired, err := inst.AcquireLeadership("ins_1", expiresAt)
+	if err != nil || !acquired || token != 1 {
+		t.Fatalf("expected the leadership with token 1, got %d %v %v", token, acquired, err)
+	}
+	if _, acquired, _ := inst.AcquireLeadership("ins_2", expiresAt); acquired {
+		t.Fatal("expected a single leader")
+	}
+	if renewed, acquired, _ := inst.AcquireLeadership("ins_1", expiresAt); !acquired || renewed != token {
+		t.Fatalf("expected the leader to keep its token, got %d", renewed)
+	}
+	if claimed, err := inst.ClaimLease("ins_1", "job_1", token, expiresAt); err != nil || !claimed {
+		t.Fatalf("expected the leader to claim the job, got %v %v", claimed, err)
+	}
+	if claimed, _ := inst.ClaimLease("ins_2", "job_1", 0, expiresAt); claimed {
+		t.Fatal("expected the lease of another owner to be kept")
+	}
+
+	// The leadership expires and changes hands, the stale token can't claim jobs anymore.
+	mr.FastForward(2 * time.Minute)
+	newToken, acquired, _ := inst.AcquireLeadership("ins_2", time.Now().Add(time.Minute))
+	if !acquired || newToken != token+1 {
+		t.Fatalf("expected the leadership to change hands with a new token, got %d %v", newToken, acquired)
+	}
+	if claimed, _ := inst.ClaimLease("ins_1", "job_2", token, time.Now().Add(time.Minute)); claimed {
+		t.Fatal("expected the stale token to be fenced off")
+	}
+	if err := inst.ReleaseLeadership("ins_2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, acquired, _ := inst.AcquireLeadership("ins_1", time.Now().Add(time.Minute)); !acquired {
+		t.Fatal("expected the released leadership to be available")
+	}
+}
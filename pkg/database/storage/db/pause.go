@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/go-redis/redis/v8"
+)
+
+// GetPause fetches the paused state of the scheduler from the storage.
+func (inst *Redis) GetPause() (*model.Pause, error) {
+	return decodePause(inst.Get(ctx, inst.GetRedisPrefixedKey(pause)))
+}
+
+// UpdatePause applies the update to the paused state of the scheduler, the state is watched so the update is applied
+// again on top of a concurrent one.
+func (inst *Redis) UpdatePause(update func(p *model.Pause)) (*model.Pause, error) {
+	key := inst.GetRedisPrefixedKey(pause)
+	for {
+		var p *model.Pause
+		err := inst.Watch(ctx, func(tx *redis.Tx) error {
+			var err error
+			if p, err = decodePause(tx.Get(ctx, key)); err != nil {
+				return err
+			}
+			update(p)
+			value, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, value, 0)
+				return nil
+			})
+			return err
+		}, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// decodePause decodes the paused state, nothing is paused if it was never stored.
+func decodePause(cmd *redis.StringCmd) (*model.Pause, error) {
+	p := &model.Pause{}
+	val, err := cmd.Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return p, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(val, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+func TestRedis_Pause(t *testing.T) {
+	inst, _ := newTestRedis(t)
+	p, err := inst.GetPause()
+	if err != nil || p.Global || len(p.Queues) != 0 {
+		t.Fatalf("expected nothing paused, got %v, %v", p, err)
+	}
+
+	// The concurrent pauses don't overwrite each other.
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(queue string) {
+			defer wg.Done()
+			if _, err := inst.UpdatePause(func(p *model.Pause) {
+				p.PauseQueue(queue, &now)
+			}); err != nil {
+				t.Error(err)
+			}
+		}(fmt.Sprintf("queue_%d", i))
+	}
+	wg.Wait()
+	p, err = inst.GetPause()
+	if err != nil || len(p.Queues) != 5 {
+		t.Fatalf("expected the 5 queues paused, got %v, %v", p, err)
+	}
+
+	p, err = inst.UpdatePause(func(p *model.Pause) {
+		p.ResumeQueue("queue_0", &now)
+	})
+	if err != nil || p.IsPaused("queue_0") || !p.IsPaused("queue_1") {
+		t.Fatalf("expected queue_0 to be resumed only, got %v, %v", p, err)
+	}
+}
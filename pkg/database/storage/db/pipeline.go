@@ -9,7 +9,6 @@ import (
 	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
 	"github.com/go-redis/redis/v8"
 	"sort"
-	"time"
 )
 
 // CreatePipeline adds a new pipeline and of its jobs to the storage.
@@ -18,12 +17,11 @@ func (inst *Redis) CreatePipeline(p *model.Pipeline) error {
 	p.RunAtUUID = runAtUUID
 	err := inst.Watch(ctx, func(tx *redis.Tx) error {
 		for _, j := range p.Jobs {
-			key := inst.getRedisKeyForJob(j.UUID)
-			value, err := json.Marshal(j)
+			old, err := inst.getJobIfExists(j.UUID)
 			if err != nil {
 				return err
 			}
-			err = inst.Set(ctx, key, value, 0).Err()
+			err = inst.saveJob(old, j)
 			if err != nil {
 				return err
 			}
@@ -110,28 +108,29 @@ func (inst *Redis) RecyclePipeline(id string, p *model.Pipeline) (*model.Pipelin
 		return nil, err
 	}
 
-	var nextRunTime = time.Time{}
+	runAts, err := automater.RecyclePipelineRunAts(p, jobs)
+	if err != nil {
+		return nil, err
+	}
 	var recycleJobs []*model.Job
 	for i, job := range jobs {
-		now, err := automater.PipelineRunAt(p.PipelineOptions.RunOnInterval, p.PipelineOptions, i)
-		runAtTime := now.Add(time.Millisecond * time.Duration(i+2)) // in db GetDueJobs it orders by time desc, so we need a small buffer (this is a hack)
-		if err != nil {
-			return nil, err
-		}
-		job.RunAt = &runAtTime
+		job.RunAt = runAts[i]
 		recycleJob, err := inst.Recycle(job.UUID, job) // recycle jobs
 		if err != nil {
 			return nil, err
 		}
-		recycleJob.RunAt = &nextRunTime
 		recycleJobs = append(recycleJobs, recycleJob)
 	}
 
 	getExisting.Jobs = recycleJobs
 	getExisting.Status = model.Pending
-	getExisting.RunAt = &nextRunTime
+	getExisting.RunAt = nil
+	if len(recycleJobs) > 0 {
+		getExisting.RunAt = recycleJobs[0].RunAt
+	}
 	getExisting.StartedAt = nil
 	getExisting.Duration = nil
+	getExisting.RunVars = getExisting.TriggerVars
 
 	err = inst.UpdatePipeline(id, getExisting)
 	if err != nil {
@@ -161,49 +160,29 @@ func (inst *Redis) UpdatePipeline(uuid string, p *model.Pipeline) error {
 
 // DeletePipeline deletes a pipeline and all its jobs from the storage.
 func (inst *Redis) DeletePipeline(uuid string) error {
+	if err := inst.ensureIndexes(); err != nil {
+		return err
+	}
 	err := inst.Watch(ctx, func(tx *redis.Tx) error {
-		var keys []string
-		key := inst.GetRedisPrefixedKey("job:*")
-		iter := inst.Scan(ctx, 0, key, 0).Iterator()
-		for iter.Next(ctx) {
-			keys = append(keys, iter.Val())
-		}
-		if err := iter.Err(); err != nil {
+		jobIDs, err := inst.SMembers(ctx, inst.jobPipelineIndexKey(uuid)).Result()
+		if err != nil {
 			return err
 		}
-
-		var jobs []*model.Job
-		for _, key := range keys {
-			value, err := inst.Get(ctx, key).Bytes()
-			if err != nil {
-				return err
-			}
-			j := &model.Job{}
-			if err := json.Unmarshal(value, j); err != nil {
-				return err
-			}
-			if j.PipelineID == uuid {
-				jobs = append(jobs, j)
-			}
-		}
-		for _, j := range jobs {
-			key = inst.getRedisKeyForJobResult(j.UUID)
-			_, err := inst.Del(ctx, key).Result()
-			if err != nil {
-				return err
-			}
-			key = inst.getRedisKeyForJob(j.UUID)
-			_, err = inst.Del(ctx, key).Result()
-			if err != nil {
-				return err
-			}
-		}
-		key = inst.getRedisKeyForPipeline(uuid)
-		_, err := inst.Del(ctx, key).Result()
+		jobs, err := inst.getJobsByUUIDs(jobIDs)
 		if err != nil {
 			return err
 		}
-		return nil
+		_, err = inst.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, j := range jobs {
+				pipe.Del(ctx, inst.getRedisKeyForJobResult(j.UUID))
+				pipe.Del(ctx, inst.getRedisKeyForJob(j.UUID))
+				inst.unindexJob(pipe, j)
+			}
+			pipe.Del(ctx, inst.jobPipelineIndexKey(uuid))
+			pipe.Del(ctx, inst.getRedisKeyForPipeline(uuid))
+			return nil
+		})
+		return err
 	})
 	if err != nil {
 		return err
@@ -2,7 +2,6 @@ package redis
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/NubeIO/rubix-automater/automater/model"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
 	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
@@ -37,6 +36,7 @@ func (inst *Redis) CreateTransaction(job *model.Job) (*model.Transaction, error)
 		IsPipeLine:    isPipeLine,
 		RunAtUUID:     runAtUUID,
 		Status:        job.Status,
+		Attempt:       job.Attempt,
 		FailureReason: job.FailureReason,
 		StartedAt:     job.StartedAt,
 		CreatedAt:     &now,
@@ -48,7 +48,11 @@ func (inst *Redis) CreateTransaction(job *model.Job) (*model.Transaction, error)
 	if err != nil {
 		return nil, err
 	}
-	err = inst.Set(ctx, key, value, 0).Err()
+	_, err = inst.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, 0)
+		inst.indexTransaction(pipe, trans)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +69,7 @@ func (inst *Redis) CreateTransaction(job *model.Job) (*model.Transaction, error)
 		TaskType:      tran.TaskType,
 		SubTaskType:   tran.SubTaskType,
 		Status:        tran.Status.String(),
+		Attempt:       tran.Attempt,
 		RunAtUUID:     tran.RunAtUUID,
 		FailureReason: tran.FailureReason,
 		CreatedAt:     tran.CreatedAt,
@@ -82,59 +87,55 @@ func (inst *Redis) CreateTransaction(job *model.Job) (*model.Transaction, error)
 
 // DeleteTransaction deletes a trans from the storage.
 func (inst *Redis) DeleteTransaction(uuid string) error {
-	key := inst.getRedisKeyForTransaction(uuid)
-	_, err := inst.Del(ctx, key).Result()
+	t, err := inst.GetTransaction(uuid)
 	if err != nil {
+		if _, ok := err.(*apperrors.NotFoundErr); ok {
+			return nil
+		}
 		return err
 	}
-	return nil
+	_, err = inst.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, inst.getRedisKeyForTransaction(uuid))
+		inst.unindexTransaction(pipe, t)
+		return nil
+	})
+	return err
 }
 
 // GetTransactions fetches all trans from the storage, optionally filters the jobs by status.
 func (inst *Redis) GetTransactions(status model.JobStatus) ([]*model.Transaction, error) {
-	var keys []string
-	key := inst.GetRedisPrefixedKey(fmt.Sprintf("%s:*", transaction))
-	iter := inst.Scan(ctx, 0, key, 0).Iterator()
-	for iter.Next(ctx) {
-		keys = append(keys, iter.Val())
-	}
-	if err := iter.Err(); err != nil {
+	if err := inst.ensureIndexes(); err != nil {
 		return nil, err
 	}
-	var jobs []*model.Transaction
-	for _, key := range keys {
-		value, err := inst.Get(ctx, key).Bytes()
-		if err != nil {
-			return nil, err
-		}
-		j := &model.Transaction{}
-		if err := json.Unmarshal(value, j); err != nil {
-			return nil, err
-		}
-		if status == model.Undefined || j.Status == status {
-			jobs = append(jobs, j)
-		}
+	key := inst.transactionIndexKey("all")
+	if status != model.Undefined {
+		key = inst.transactionStatusIndexKey(status)
 	}
-	// ORDER BY created_at ASC
-	sort.Slice(jobs, func(i, j int) bool {
-		return jobs[i].CreatedAt.Before(*jobs[j].CreatedAt)
-	})
-	return jobs, nil
+	return inst.getTransactionsByIndex(key)
 }
 
 // GetTransactionsByJob fetches all trans from the storage by job
 func (inst *Redis) GetTransactionsByJob(jobId string) ([]*model.Transaction, error) {
-	var jobs []*model.Transaction
-	transactions, err := inst.GetTransactions(model.Undefined)
+	if err := inst.ensureIndexes(); err != nil {
+		return nil, err
+	}
+	return inst.getTransactionsByIndex(inst.transactionJobIndexKey(jobId))
+}
+
+func (inst *Redis) getTransactionsByIndex(key string) ([]*model.Transaction, error) {
+	uuids, err := inst.SMembers(ctx, key).Result()
 	if err != nil {
 		return nil, err
 	}
-	for _, t := range transactions {
-		if t.JobID == jobId {
-			jobs = append(jobs, t)
-		}
+	transactions, err := inst.getTransactionsByUUIDs(uuids)
+	if err != nil {
+		return nil, err
 	}
-	return jobs, nil
+	// ORDER BY created_at ASC
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(*transactions[j].CreatedAt)
+	})
+	return transactions, nil
 }
 
 // GetTransaction fetches a trans from the storage.
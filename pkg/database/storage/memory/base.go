@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+var _ automater.Storage = &Memory{}
+
+// Memory represents an in-process storage. Every resource is kept JSON encoded,
+// so callers never share pointers with the storage, just like with redis.
+type Memory struct {
+	mu           sync.RWMutex
+	jobs         map[string][]byte
+	pipelines    map[string][]byte
+	results      map[string][]byte
+	transactions map[string][]byte
+	blackouts    map[string][]byte
+	pause        []byte
+	leases       map[string][]byte
+	leadership   *model.Leadership
+}
+
+// New returns an in-memory storage.
+func New() *Memory {
+	inst := &Memory{}
+	inst.reset()
+	return inst
+}
+
+func (inst *Memory) reset() {
+	inst.jobs = make(map[string][]byte)
+	inst.pipelines = make(map[string][]byte)
+	inst.results = make(map[string][]byte)
+	inst.transactions = make(map[string][]byte)
+	inst.blackouts = make(map[string][]byte)
+	inst.pause = nil
+	inst.leases = make(map[string][]byte)
+	inst.leadership = &model.Leadership{}
+}
+
+// WipeDB wipes the db.
+func (inst *Memory) WipeDB() error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	inst.reset()
+	return nil
+}
+
+// CheckHealth checks if the storage is alive.
+func (inst *Memory) CheckHealth() bool {
+	return true
+}
+
+// Close liberates the bound resources of the storage.
+func (inst *Memory) Close() error {
+	return nil
+}
+
+func put(store map[string][]byte, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	store[key] = value
+	return nil
+}
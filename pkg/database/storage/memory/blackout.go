@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+// CreateBlackout adds a new blackout to the storage.
+func (inst *Memory) CreateBlackout(b *model.Blackout) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.blackouts, b.UUID, b)
+}
+
+// GetBlackout fetches a blackout from the storage.
+func (inst *Memory) GetBlackout(uuid string) (*model.Blackout, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	value, ok := inst.blackouts[uuid]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "blackout"}
+	}
+	var b *model.Blackout
+	if err := json.Unmarshal(value, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlackouts fetches all blackouts from the storage.
+func (inst *Memory) GetBlackouts() ([]*model.Blackout, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	var blackouts []*model.Blackout
+	for _, value := range inst.blackouts {
+		b := &model.Blackout{}
+		if err := json.Unmarshal(value, b); err != nil {
+			return nil, err
+		}
+		blackouts = append(blackouts, b)
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(blackouts, func(i, j int) bool {
+		return blackouts[i].CreatedAt.Before(*blackouts[j].CreatedAt)
+	})
+	return blackouts, nil
+}
+
+// UpdateBlackout updates a blackout to the storage.
+func (inst *Memory) UpdateBlackout(uuid string, b *model.Blackout) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.blackouts, uuid, b)
+}
+
+// DeleteBlackout deletes a blackout from the storage.
+func (inst *Memory) DeleteBlackout(uuid string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	delete(inst.blackouts, uuid)
+	return nil
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+// CreateJob adds a new job to the storage.
+func (inst *Memory) CreateJob(j *model.Job) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.jobs, j.UUID, j)
+}
+
+// GetJob fetches a job from the storage.
+func (inst *Memory) GetJob(uuid string) (*model.Job, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getJob(uuid)
+}
+
+func (inst *Memory) getJob(uuid string) (*model.Job, error) {
+	value, ok := inst.jobs[uuid]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "job"}
+	}
+	var j *model.Job
+	if err := json.Unmarshal(value, &j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (inst *Memory) getAllJobs() ([]*model.Job, error) {
+	jobs := make([]*model.Job, 0, len(inst.jobs))
+	for _, value := range inst.jobs {
+		j := &model.Job{}
+		if err := json.Unmarshal(value, j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// GetJobs fetches all jobs from the storage, optionally filters the jobs by status.
+func (inst *Memory) GetJobs(status model.JobStatus) ([]*model.Job, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	all, err := inst.getAllJobs()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.Job
+	for _, j := range all {
+		if status == model.Undefined || j.Status == status {
+			jobs = append(jobs, j)
+		}
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(*jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// GetJobsByPipelineID fetches the jobs of the specified pipeline.
+func (inst *Memory) GetJobsByPipelineID(pipelineID string) ([]*model.Job, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getJobsByPipelineID(pipelineID)
+}
+
+func (inst *Memory) getJobsByPipelineID(pipelineID string) ([]*model.Job, error) {
+	p, err := inst.getPipeline(pipelineID)
+	if err != nil {
+		if _, ok := err.(*apperrors.NotFoundErr); ok {
+			// Mimic the relational storages behavior.
+			return []*model.Job{}, nil
+		}
+		return nil, err
+	}
+	return p.Jobs, nil
+}
+
+// Recycle updates a job to the storage.
+func (inst *Memory) Recycle(uuid string, j *model.Job) (*model.Job, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return inst.recycle(uuid, j)
+}
+
+func (inst *Memory) recycle(uuid string, j *model.Job) (*model.Job, error) {
+	if _, err := inst.getJob(uuid); err != nil {
+		return nil, err
+	}
+	if err := automater.RecycleRunAt(j); err != nil {
+		return nil, err
+	}
+	now := ttime.New().Now()
+	j.UUID = uuid
+	j.Status = model.Pending
+	j.Attempt = 0
+	j.ScheduledAt = nil
+	j.StartedAt = nil
+	j.CreatedAt = &now
+	if err := put(inst.jobs, uuid, j); err != nil {
+		return nil, err
+	}
+	return inst.getJob(uuid)
+}
+
+// UpdateJob updates a job to the storage.
+func (inst *Memory) UpdateJob(uuid string, j *model.Job) (*model.Job, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if err := put(inst.jobs, uuid, j); err != nil {
+		return nil, err
+	}
+	if j.BelongsToPipeline() {
+		// Sync pipeline job.
+		p, err := inst.getPipeline(j.PipelineID)
+		if err == nil {
+			for i, job := range p.Jobs {
+				if job.UUID == j.UUID {
+					p.Jobs[i] = j
+				}
+			}
+			if err := put(inst.pipelines, p.UUID, p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return inst.getJob(uuid)
+}
+
+// DeleteJob deletes a job from the storage.
+func (inst *Memory) DeleteJob(uuid string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	byJob, err := inst.getTransactionsByJob(uuid)
+	if err != nil {
+		return err
+	}
+	for _, t := range byJob {
+		delete(inst.transactions, t.UUID)
+	}
+	delete(inst.jobs, uuid)
+	return nil
+}
+
+// GetNextRunAt returns the earliest run_at of the pending jobs due from the given time, nil if there's none.
+func (inst *Memory) GetNextRunAt(after time.Time) (*time.Time, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	all, err := inst.getAllJobs()
+	if err != nil {
+		return nil, err
+	}
+	var next *time.Time
+	for _, j := range all {
+		if j.IsScheduled() && j.Status == model.Pending && !j.RunAt.Before(after) && (next == nil || j.RunAt.Before(*next)) {
+			next = j.RunAt
+		}
+	}
+	return next, nil
+}
+
+// GetDueJobs fetches all jobs scheduled to run before now and have not been scheduled yet.
+func (inst *Memory) GetDueJobs() ([]*model.Job, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	all, err := inst.getAllJobs()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var dueJobs []*model.Job
+	for _, j := range all {
+		if j.IsScheduled() && j.RunAt.Before(now) && j.Status == model.Pending {
+			dueJobs = append(dueJobs, j)
+		}
+	}
+	// ORDER BY run_at ASC
+	sort.Slice(dueJobs, func(i, j int) bool {
+		return dueJobs[i].RunAt.Before(*dueJobs[j].RunAt)
+	})
+	return dueJobs, nil
+}
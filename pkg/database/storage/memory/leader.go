@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// AcquireLeadership claims or extends the scheduler leadership for the owner until expiresAt.
+func (inst *Memory) AcquireLeadership(owner string, expiresAt time.Time) (int64, bool, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	now := time.Now()
+	l := inst.leadership
+	if l.Owner != owner && !l.IsExpired(now) {
+		return 0, false, nil
+	}
+	if l.Owner != owner || l.IsExpired(now) {
+		// The leadership changes hands.
+		l.Token++
+	}
+	l.Owner = owner
+	l.ExpiresAt = &expiresAt
+	return l.Token, true, nil
+}
+
+// ReleaseLeadership gives the scheduler leadership up if the owner holds it.
+func (inst *Memory) ReleaseLeadership(owner string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if inst.leadership.Owner == owner {
+		inst.leadership.ExpiresAt = nil
+	}
+	return nil
+}
+
+// ClaimLease claims the lease of the job for the owner until expiresAt, with the fencing token of the leadership.
+func (inst *Memory) ClaimLease(owner, jobID string, token int64, expiresAt time.Time) (bool, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if token > 0 && token != inst.leadership.Token {
+		return false, nil
+	}
+	if value, ok := inst.leases[jobID]; ok {
+		l := &model.Lease{}
+		if err := json.Unmarshal(value, l); err != nil {
+			return false, err
+		}
+		if l.Owner != owner && !l.IsExpired(time.Now()) {
+			return false, nil
+		}
+	}
+	return true, put(inst.leases, jobID, model.NewLease(jobID, owner, &expiresAt))
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// RenewLeases claims or extends the leases of the jobs for the owner until expiresAt.
+func (inst *Memory) RenewLeases(owner string, jobIDs []string, expiresAt time.Time) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	for _, id := range jobIDs {
+		if err := put(inst.leases, id, model.NewLease(id, owner, &expiresAt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLeases fetches the leases of the jobs.
+func (inst *Memory) GetLeases(jobIDs []string) (map[string]*model.Lease, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	leases := make(map[string]*model.Lease)
+	for _, id := range jobIDs {
+		value, ok := inst.leases[id]
+		if !ok {
+			continue
+		}
+		l := &model.Lease{}
+		if err := json.Unmarshal(value, l); err != nil {
+			return nil, err
+		}
+		leases[id] = l
+	}
+	return leases, nil
+}
+
+// ReleaseLeases deletes the leases of the jobs.
+func (inst *Memory) ReleaseLeases(jobIDs []string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	for _, id := range jobIDs {
+		delete(inst.leases, id)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+func newTestJob(uuid, pipelineID string, runAt time.Time) *model.Job {
+	createdAt := time.Now()
+	return model.NewJob(uuid, uuid, "task", "", "", pipelineID, "", 0,
+		&runAt, &createdAt, false, false, nil, nil)
+}
+
+func TestMemory_Jobs(t *testing.T) {
+	inst := New()
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	if err := inst.CreateJob(newTestJob("job_1", "", past)); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.CreateJob(newTestJob("job_2", "", future)); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := inst.GetJob("job_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The storage must not share memory with the caller.
+	j.Name = "changed"
+	if stored, _ := inst.GetJob("job_1"); stored.Name != "job_1" {
+		t.Fatalf("expected stored job to be unchanged, got name %s", stored.Name)
+	}
+
+	due, err := inst.GetDueJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].UUID != "job_1" {
+		t.Fatalf("expected job_1 to be due, got %v", due)
+	}
+
+	j.MarkScheduled(&past)
+	if _, err := inst.UpdateJob(j.UUID, j); err != nil {
+		t.Fatal(err)
+	}
+	scheduled, _ := inst.GetJobs(model.Scheduled)
+	if len(scheduled) != 1 {
+		t.Fatalf("expected 1 scheduled job, got %d", len(scheduled))
+	}
+
+	if _, err := inst.CreateTransaction(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.DeleteJob(j.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.GetJob(j.UUID); err == nil {
+		t.Fatal("expected job to be deleted")
+	}
+	transactions, _ := inst.GetTransactions(model.Undefined)
+	if len(transactions) != 0 {
+		t.Fatalf("expected job transactions to be deleted, got %d", len(transactions))
+	}
+}
+
+func TestMemory_Pipeline(t *testing.T) {
+	inst := New()
+	now := time.Now()
+	jobs := []*model.Job{
+		newTestJob("job_1", "pip_1", now),
+		newTestJob("job_2", "pip_1", now),
+	}
+	jobs[0].NextJobID = "job_2"
+	p := model.NewPipeline("pip_1", "pipeline", "", &model.PipelineOptions{RunOnInterval: "1 min"}, jobs, &now)
+	if err := inst.CreatePipeline(p); err != nil {
+		t.Fatal(err)
+	}
+
+	j, _ := inst.GetJob("job_2")
+	j.MarkCompleted(&now)
+	if _, err := inst.UpdateJob(j.UUID, j); err != nil {
+		t.Fatal(err)
+	}
+	pipelineJobs, err := inst.GetJobsByPipelineID("pip_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pipelineJobs[1].Status != model.Completed {
+		t.Fatalf("expected pipeline job to be synced, got %s", pipelineJobs[1].Status)
+	}
+
+	p, _ = inst.GetPipeline("pip_1")
+	p, err = inst.RecyclePipeline(p.UUID, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != model.Pending || !p.RunAt.After(now) {
+		t.Fatalf("expected recycled pipeline to be pending in the future, got %s at %s", p.Status, p.RunAt)
+	}
+	if !p.RunAt.Equal(*p.Jobs[0].RunAt) || !p.Jobs[1].RunAt.After(*p.Jobs[0].RunAt) {
+		t.Fatalf("expected recycled jobs to run one after the other from %s, got %s and %s",
+			p.RunAt, p.Jobs[0].RunAt, p.Jobs[1].RunAt)
+	}
+	for _, job := range p.Jobs {
+		if job.Status != model.Pending {
+			t.Fatalf("expected recycled job %s to be pending, got %s", job.UUID, job.Status)
+		}
+	}
+
+	if err := inst.DeletePipeline("pip_1"); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := inst.GetJobs(model.Undefined); len(all) != 0 {
+		t.Fatalf("expected pipeline jobs to be deleted, got %d", len(all))
+	}
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// GetPause fetches the paused state of the scheduler from the storage.
+func (inst *Memory) GetPause() (*model.Pause, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	p := &model.Pause{}
+	if inst.pause == nil {
+		return p, nil
+	}
+	if err := json.Unmarshal(inst.pause, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdatePause applies the update to the paused state of the scheduler under the storage lock.
+func (inst *Memory) UpdatePause(update func(p *model.Pause)) (*model.Pause, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	p := &model.Pause{}
+	if inst.pause != nil {
+		if err := json.Unmarshal(inst.pause, p); err != nil {
+			return nil, err
+		}
+	}
+	update(p)
+	value, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	inst.pause = value
+	return p, nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+)
+
+// CreatePipeline adds a new pipeline and of its jobs to the storage.
+func (inst *Memory) CreatePipeline(p *model.Pipeline) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	for _, j := range p.Jobs {
+		if err := put(inst.jobs, j.UUID, j); err != nil {
+			return err
+		}
+	}
+	return put(inst.pipelines, p.UUID, p)
+}
+
+// GetPipeline fetches a pipeline from the storage.
+func (inst *Memory) GetPipeline(uuid string) (*model.Pipeline, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getPipeline(uuid)
+}
+
+func (inst *Memory) getPipeline(uuid string) (*model.Pipeline, error) {
+	value, ok := inst.pipelines[uuid]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "pipeline"}
+	}
+	var p *model.Pipeline
+	if err := json.Unmarshal(value, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPipelines fetches all pipelines from the storage, optionally filters the pipelines by status.
+func (inst *Memory) GetPipelines(status model.JobStatus) ([]*model.Pipeline, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	var pipelines []*model.Pipeline
+	for _, value := range inst.pipelines {
+		p := &model.Pipeline{}
+		if err := json.Unmarshal(value, p); err != nil {
+			return nil, err
+		}
+		if status == model.Undefined || p.Status == status {
+			pipelines = append(pipelines, p)
+		}
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(pipelines, func(i, j int) bool {
+		return pipelines[i].CreatedAt.Before(*pipelines[j].CreatedAt)
+	})
+	return pipelines, nil
+}
+
+// RecyclePipeline updates a pipeline to the storage.
+func (inst *Memory) RecyclePipeline(id string, p *model.Pipeline) (*model.Pipeline, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	jobs, err := inst.getJobsByPipelineID(id) // get the existing pipeline jobs
+	if err != nil {
+		return nil, err
+	}
+	runAts, err := automater.RecyclePipelineRunAts(p, jobs)
+	if err != nil {
+		return nil, err
+	}
+	var recycleJobs []*model.Job
+	for i, job := range jobs {
+		job.RunAt = runAts[i]
+		recycleJob, err := inst.recycle(job.UUID, job) // recycle jobs
+		if err != nil {
+			return nil, err
+		}
+		recycleJobs = append(recycleJobs, recycleJob)
+	}
+
+	p.Jobs = recycleJobs
+	p.Status = model.Pending
+	p.RunAt = nil
+	if len(recycleJobs) > 0 {
+		p.RunAt = recycleJobs[0].RunAt
+	}
+	p.StartedAt = nil
+	p.Duration = nil
+	p.RunVars = p.TriggerVars
+	if err := put(inst.pipelines, id, p); err != nil {
+		return nil, err
+	}
+	return inst.getPipeline(id)
+}
+
+// UpdatePipeline updates a pipeline to the storage.
+func (inst *Memory) UpdatePipeline(uuid string, p *model.Pipeline) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.pipelines, uuid, p)
+}
+
+// DeletePipeline deletes a pipeline and all its jobs from the storage.
+func (inst *Memory) DeletePipeline(uuid string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	jobs, err := inst.getAllJobs()
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if j.PipelineID == uuid {
+			delete(inst.results, j.UUID)
+			delete(inst.jobs, j.UUID)
+		}
+	}
+	delete(inst.pipelines, uuid)
+	return nil
+}
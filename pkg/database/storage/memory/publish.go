@@ -0,0 +1,6 @@
+package memory
+
+// Pub is a no-op, there is no broker to publish to when running in-process.
+func (inst *Memory) Pub(channel string, message interface{}) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+// CreateJobResult adds a new job result to the storage.
+func (inst *Memory) CreateJobResult(result *model.JobResult) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.results, result.JobID, result)
+}
+
+// GetJobResult fetches a job result from the storage.
+func (inst *Memory) GetJobResult(jobID string) (*model.JobResult, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	value, ok := inst.results[jobID]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: jobID, ResourceName: "job result"}
+	}
+	var result *model.JobResult
+	if err := json.Unmarshal(value, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateJobResult updates a job result to the storage.
+func (inst *Memory) UpdateJobResult(jobID string, result *model.JobResult) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return put(inst.results, jobID, result)
+}
+
+// DeleteJobResult deletes a job result from the storage.
+func (inst *Memory) DeleteJobResult(jobID string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	delete(inst.results, jobID)
+	return nil
+}
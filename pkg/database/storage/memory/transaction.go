@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+)
+
+// CreateTransaction adds a new trans result to the storage.
+func (inst *Memory) CreateTransaction(job *model.Job) (*model.Transaction, error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	id, _ := uuid.New().Make("tra")
+	now := ttime.New().Now()
+	isPipeLine := false
+	runAtUUID := "false"
+	if job.PipelineID != "" {
+		isPipeLine = true
+		getPipeline, err := inst.getPipeline(job.PipelineID)
+		if err != nil {
+			return nil, err
+		}
+		runAtUUID = getPipeline.RunAtUUID
+	}
+	trans := &model.Transaction{
+		UUID:          id,
+		PipelineID:    job.PipelineID,
+		JobID:         job.UUID,
+		TaskType:      job.TaskName,
+		SubTaskType:   job.SubTaskName,
+		IsPipeLine:    isPipeLine,
+		RunAtUUID:     runAtUUID,
+		Status:        job.Status,
+		Attempt:       job.Attempt,
+		FailureReason: job.FailureReason,
+		StartedAt:     job.StartedAt,
+		CreatedAt:     &now,
+		CompletedAt:   job.CompletedAt,
+		Duration:      job.Duration,
+	}
+	if err := put(inst.transactions, id, trans); err != nil {
+		return nil, err
+	}
+	return inst.getTransaction(id)
+}
+
+// DeleteTransaction deletes a trans from the storage.
+func (inst *Memory) DeleteTransaction(uuid string) error {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	delete(inst.transactions, uuid)
+	return nil
+}
+
+// GetTransactions fetches all trans from the storage, optionally filters the jobs by status.
+func (inst *Memory) GetTransactions(status model.JobStatus) ([]*model.Transaction, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getTransactions(status)
+}
+
+func (inst *Memory) getTransactions(status model.JobStatus) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	for _, value := range inst.transactions {
+		t := &model.Transaction{}
+		if err := json.Unmarshal(value, t); err != nil {
+			return nil, err
+		}
+		if status == model.Undefined || t.Status == status {
+			transactions = append(transactions, t)
+		}
+	}
+	// ORDER BY created_at ASC
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(*transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// GetTransactionsByJob fetches all trans from the storage by job
+func (inst *Memory) GetTransactionsByJob(jobId string) ([]*model.Transaction, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getTransactionsByJob(jobId)
+}
+
+func (inst *Memory) getTransactionsByJob(jobId string) ([]*model.Transaction, error) {
+	var transactions []*model.Transaction
+	all, err := inst.getTransactions(model.Undefined)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		if t.JobID == jobId {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions, nil
+}
+
+// GetTransaction fetches a trans from the storage.
+func (inst *Memory) GetTransaction(uuid string) (*model.Transaction, error) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+	return inst.getTransaction(uuid)
+}
+
+func (inst *Memory) getTransaction(uuid string) (*model.Transaction, error) {
+	value, ok := inst.transactions[uuid]
+	if !ok {
+		return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "transactionctl"}
+	}
+	var t *model.Transaction
+	if err := json.Unmarshal(value, &t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
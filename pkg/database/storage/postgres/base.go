@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	_ "github.com/lib/pq"
+)
+
+var _ automater.Storage = &Postgres{}
+
+// Postgres represents a PostgreSQL storage.
+type Postgres struct {
+	db *sql.DB
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// New connects to the database under url and applies any pending schema migrations.
+func New(url string, maxOpenConns int) (*Postgres, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if maxOpenConns > 0 {
+		db.SetMaxOpenConns(maxOpenConns)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Postgres{db: db}, nil
+}
+
+// WipeDB wipes the db.
+func (inst *Postgres) WipeDB() error {
+	_, err := inst.db.Exec(`TRUNCATE jobs, pipelines, job_results, transactions, blackouts, settings, job_leases, leaders`)
+	return err
+}
+
+// CheckHealth checks if the storage is alive.
+func (inst *Postgres) CheckHealth() bool {
+	return inst.db.Ping() == nil
+}
+
+// Close terminates any storage connections gracefully.
+func (inst *Postgres) Close() error {
+	return inst.db.Close()
+}
+
+// withTx runs fn in a database transaction, committing it only if fn succeeds.
+func (inst *Postgres) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := inst.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanAll decodes the single JSON column of every row into a new value built by newFn.
+func scanAll(rows *sql.Rows, newFn func() interface{}) error {
+	defer rows.Close()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, newFn()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+// CreateBlackout adds a new blackout to the storage.
+func (inst *Postgres) CreateBlackout(b *model.Blackout) error {
+	return inst.UpdateBlackout(b.UUID, b)
+}
+
+// GetBlackout fetches a blackout from the storage.
+func (inst *Postgres) GetBlackout(uuid string) (*model.Blackout, error) {
+	var data []byte
+	err := inst.db.QueryRow(`SELECT data FROM blackouts WHERE uuid = $1`, uuid).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "blackout"}
+		}
+		return nil, err
+	}
+	var b *model.Blackout
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlackouts fetches all blackouts from the storage.
+func (inst *Postgres) GetBlackouts() ([]*model.Blackout, error) {
+	rows, err := inst.db.Query(`SELECT data FROM blackouts ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	var blackouts []*model.Blackout
+	err = scanAll(rows, func() interface{} {
+		b := &model.Blackout{}
+		blackouts = append(blackouts, b)
+		return b
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blackouts, nil
+}
+
+// UpdateBlackout updates a blackout to the storage.
+func (inst *Postgres) UpdateBlackout(uuid string, b *model.Blackout) error {
+	value, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = inst.db.Exec(`
+		INSERT INTO blackouts (uuid, created_at, data) VALUES ($1, $2, $3)
+		ON CONFLICT (uuid) DO UPDATE SET data = EXCLUDED.data`, uuid, b.CreatedAt, value)
+	return err
+}
+
+// DeleteBlackout deletes a blackout from the storage.
+func (inst *Postgres) DeleteBlackout(uuid string) error {
+	_, err := inst.db.Exec(`DELETE FROM blackouts WHERE uuid = $1`, uuid)
+	return err
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+)
+
+// putJob upserts a job, keeping its position in the pipeline if it already exists.
+func putJob(q querier, j *model.Job, position int) error {
+	value, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`
+		INSERT INTO jobs (uuid, pipeline_id, position, status, run_at, created_at, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (uuid) DO UPDATE SET
+			pipeline_id = EXCLUDED.pipeline_id,
+			status = EXCLUDED.status,
+			run_at = EXCLUDED.run_at,
+			created_at = EXCLUDED.created_at,
+			data = EXCLUDED.data`,
+		j.UUID, j.PipelineID, position, j.Status.Index(), j.RunAt, j.CreatedAt, value)
+	return err
+}
+
+func getJob(q querier, uuid string) (*model.Job, error) {
+	var data []byte
+	err := q.QueryRow(`SELECT data FROM jobs WHERE uuid = $1`, uuid).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "job"}
+		}
+		return nil, err
+	}
+	var j *model.Job
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func queryJobs(q querier, query string, args ...interface{}) ([]*model.Job, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.Job
+	err = scanAll(rows, func() interface{} {
+		j := &model.Job{}
+		jobs = append(jobs, j)
+		return j
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// CreateJob adds a new job to the storage.
+func (inst *Postgres) CreateJob(j *model.Job) error {
+	return putJob(inst.db, j, 0)
+}
+
+// GetJob fetches a job from the storage.
+func (inst *Postgres) GetJob(uuid string) (*model.Job, error) {
+	return getJob(inst.db, uuid)
+}
+
+// GetJobs fetches all jobs from the storage, optionally filters the jobs by status.
+func (inst *Postgres) GetJobs(status model.JobStatus) ([]*model.Job, error) {
+	if status == model.Undefined {
+		return queryJobs(inst.db, `SELECT data FROM jobs ORDER BY created_at ASC`)
+	}
+	return queryJobs(inst.db, `SELECT data FROM jobs WHERE status = $1 ORDER BY created_at ASC`, status.Index())
+}
+
+// GetJobsByPipelineID fetches the jobs of the specified pipeline.
+func (inst *Postgres) GetJobsByPipelineID(pipelineID string) ([]*model.Job, error) {
+	return getJobsByPipelineID(inst.db, pipelineID)
+}
+
+func getJobsByPipelineID(q querier, pipelineID string) ([]*model.Job, error) {
+	jobs, err := queryJobs(q, `SELECT data FROM jobs WHERE pipeline_id = $1 ORDER BY position ASC`, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	if jobs == nil {
+		return []*model.Job{}, nil
+	}
+	return jobs, nil
+}
+
+// Recycle updates a job to the storage.
+func (inst *Postgres) Recycle(uuid string, j *model.Job) (*model.Job, error) {
+	return recycle(inst.db, uuid, j)
+}
+
+func recycle(q querier, uuid string, j *model.Job) (*model.Job, error) {
+	if _, err := getJob(q, uuid); err != nil {
+		return nil, err
+	}
+	if err := automater.RecycleRunAt(j); err != nil {
+		return nil, err
+	}
+	now := ttime.New().Now()
+	j.UUID = uuid
+	j.Status = model.Pending
+	j.Attempt = 0
+	j.ScheduledAt = nil
+	j.StartedAt = nil
+	j.CreatedAt = &now
+	if err := putJob(q, j, 0); err != nil {
+		return nil, err
+	}
+	return getJob(q, uuid)
+}
+
+// UpdateJob updates a job to the storage.
+func (inst *Postgres) UpdateJob(uuid string, j *model.Job) (*model.Job, error) {
+	j.UUID = uuid
+	if err := putJob(inst.db, j, 0); err != nil {
+		return nil, err
+	}
+	return getJob(inst.db, uuid)
+}
+
+// DeleteJob deletes a job from the storage.
+func (inst *Postgres) DeleteJob(uuid string) error {
+	return inst.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM transactions WHERE job_id = $1`, uuid); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM jobs WHERE uuid = $1`, uuid)
+		return err
+	})
+}
+
+// GetNextRunAt returns the earliest run_at of the pending jobs due from the given time, nil if there's none.
+func (inst *Postgres) GetNextRunAt(after time.Time) (*time.Time, error) {
+	var next sql.NullTime
+	err := inst.db.QueryRow(`SELECT MIN(run_at) FROM jobs WHERE status = $1 AND run_at >= $2`, model.Pending.Index(), after).Scan(&next)
+	if err != nil {
+		return nil, err
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+	return &next.Time, nil
+}
+
+// GetDueJobs fetches all jobs scheduled to run before now and have not been scheduled yet.
+func (inst *Postgres) GetDueJobs() ([]*model.Job, error) {
+	return queryJobs(inst.db, `
+		SELECT data FROM jobs
+		WHERE status = $1 AND run_at <= $2
+		ORDER BY run_at ASC`, model.Pending.Index(), time.Now())
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// leaderName is the name of the scheduler leadership in the leaders table.
+const leaderName = "scheduler"
+
+// AcquireLeadership claims or extends the scheduler leadership for the owner until expiresAt, the token is bumped
+// when the leadership changes hands.
+func (inst *Postgres) AcquireLeadership(owner string, expiresAt time.Time) (int64, bool, error) {
+	var token int64
+	err := inst.db.QueryRow(`
+		INSERT INTO leaders (name, owner, token, expires_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (name) DO UPDATE SET
+			token = CASE WHEN leaders.owner = EXCLUDED.owner AND leaders.expires_at > $4
+				THEN leaders.token ELSE leaders.token + 1 END,
+			owner = EXCLUDED.owner,
+			expires_at = EXCLUDED.expires_at
+		WHERE leaders.owner = EXCLUDED.owner OR leaders.expires_at <= $4
+		RETURNING token`, leaderName, owner, expiresAt, time.Now()).Scan(&token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return token, true, nil
+}
+
+// ReleaseLeadership gives the scheduler leadership up if the owner holds it.
+func (inst *Postgres) ReleaseLeadership(owner string) error {
+	_, err := inst.db.Exec(`
+		UPDATE leaders SET expires_at = $3 WHERE name = $1 AND owner = $2`, leaderName, owner, time.Now())
+	return err
+}
+
+// ClaimLease claims the lease of the job for the owner until expiresAt, with the fencing token of the leadership.
+func (inst *Postgres) ClaimLease(owner, jobID string, token int64, expiresAt time.Time) (bool, error) {
+	res, err := inst.db.Exec(`
+		INSERT INTO job_leases (job_id, owner, expires_at)
+		SELECT $1, $2, $3
+		WHERE $4 = 0 OR $4 = (SELECT token FROM leaders WHERE name = $6)
+		ON CONFLICT (job_id) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE job_leases.owner = EXCLUDED.owner OR job_leases.expires_at <= $5`,
+		jobID, owner, expiresAt, token, time.Now(), leaderName)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/lib/pq"
+)
+
+// RenewLeases claims or extends the leases of the jobs for the owner until expiresAt.
+func (inst *Postgres) RenewLeases(owner string, jobIDs []string, expiresAt time.Time) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	_, err := inst.db.Exec(`
+		INSERT INTO job_leases (job_id, owner, expires_at) SELECT UNNEST($1::TEXT[]), $2, $3
+		ON CONFLICT (job_id) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at`,
+		pq.Array(jobIDs), owner, expiresAt)
+	return err
+}
+
+// GetLeases fetches the leases of the jobs.
+func (inst *Postgres) GetLeases(jobIDs []string) (map[string]*model.Lease, error) {
+	leases := make(map[string]*model.Lease)
+	if len(jobIDs) == 0 {
+		return leases, nil
+	}
+	rows, err := inst.db.Query(`
+		SELECT job_id, owner, expires_at FROM job_leases WHERE job_id = ANY($1)`, pq.Array(jobIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l := &model.Lease{}
+		var expiresAt time.Time
+		if err := rows.Scan(&l.JobID, &l.Owner, &expiresAt); err != nil {
+			return nil, err
+		}
+		l.ExpiresAt = &expiresAt
+		leases[l.JobID] = l
+	}
+	return leases, rows.Err()
+}
+
+// ReleaseLeases deletes the leases of the jobs.
+func (inst *Postgres) ReleaseLeases(jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	_, err := inst.db.Exec(`DELETE FROM job_leases WHERE job_id = ANY($1)`, pq.Array(jobIDs))
+	return err
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order, each one exactly once. Never edit an applied
+// migration, append a new one instead.
+var migrations = []string{
+	// 1: initial schema.
+	`CREATE TABLE pipelines (
+		uuid       TEXT PRIMARY KEY,
+		status     SMALLINT NOT NULL,
+		created_at TIMESTAMPTZ,
+		data       JSONB NOT NULL
+	);
+	CREATE TABLE jobs (
+		uuid        TEXT PRIMARY KEY,
+		pipeline_id TEXT,
+		position    INT NOT NULL DEFAULT 0,
+		status      SMALLINT NOT NULL,
+		run_at      TIMESTAMPTZ,
+		created_at  TIMESTAMPTZ,
+		data        JSONB NOT NULL
+	);
+	CREATE INDEX jobs_status_run_at_idx ON jobs (status, run_at);
+	CREATE INDEX jobs_pipeline_id_idx ON jobs (pipeline_id, position);
+	CREATE TABLE job_results (
+		job_id TEXT PRIMARY KEY,
+		data   JSONB NOT NULL
+	);
+	CREATE TABLE transactions (
+		uuid       TEXT PRIMARY KEY,
+		job_id     TEXT NOT NULL,
+		status     SMALLINT NOT NULL,
+		created_at TIMESTAMPTZ,
+		data       JSONB NOT NULL
+	);
+	CREATE INDEX transactions_status_created_at_idx ON transactions (status, created_at);
+	CREATE INDEX transactions_job_id_idx ON transactions (job_id);`,
+	// 2: blackout windows.
+	`CREATE TABLE blackouts (
+		uuid       TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ,
+		data       JSONB NOT NULL
+	);`,
+	// 3: settings, eg: the paused state of the scheduler.
+	`CREATE TABLE settings (
+		name TEXT PRIMARY KEY,
+		data JSONB NOT NULL
+	);`,
+	// 4: job leases renewed by the worker heartbeats.
+	`CREATE TABLE job_leases (
+		job_id     TEXT PRIMARY KEY,
+		owner      TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);`,
+	// 5: scheduler leadership with its fencing token.
+	`CREATE TABLE leaders (
+		name       TEXT PRIMARY KEY,
+		owner      TEXT NOT NULL,
+		token      BIGINT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);`,
+}
+
+// migrationLockID is the advisory lock key that serializes migrations between instances.
+const migrationLockID = 7305411
+
+// migrate brings the schema up to date, recording every applied migration in schema_migrations.
+func migrate(db *sql.DB) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+	var current int
+	err = conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return err
+	}
+	for i := current; i < len(migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+)
+
+// pauseKey is the name of the paused state in the settings table.
+const pauseKey = "pause"
+
+// GetPause fetches the paused state of the scheduler from the storage.
+func (inst *Postgres) GetPause() (*model.Pause, error) {
+	p := &model.Pause{}
+	var data []byte
+	err := inst.db.QueryRow(`SELECT data FROM settings WHERE name = $1`, pauseKey).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return p, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdatePause applies the update to the paused state of the scheduler in a transaction holding its row lock.
+func (inst *Postgres) UpdatePause(update func(p *model.Pause)) (*model.Pause, error) {
+	p := &model.Pause{}
+	err := inst.withTx(func(tx *sql.Tx) error {
+		// Store the initial state first, so there's a row to lock.
+		if _, err := tx.Exec(`
+			INSERT INTO settings (name, data) VALUES ($1, '{}')
+			ON CONFLICT (name) DO NOTHING`, pauseKey); err != nil {
+			return err
+		}
+		var data []byte
+		if err := tx.QueryRow(`SELECT data FROM settings WHERE name = $1 FOR UPDATE`, pauseKey).Scan(&data); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, p); err != nil {
+			return err
+		}
+		update(p)
+		value, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE settings SET data = $2 WHERE name = $1`, pauseKey, value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater"
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+)
+
+// putPipeline upserts a pipeline, its jobs live in the jobs table.
+func putPipeline(q querier, id string, p *model.Pipeline) error {
+	stored := *p
+	stored.Jobs = nil
+	value, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`
+		INSERT INTO pipelines (uuid, status, created_at, data)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (uuid) DO UPDATE SET
+			status = EXCLUDED.status,
+			created_at = EXCLUDED.created_at,
+			data = EXCLUDED.data`,
+		id, p.Status.Index(), p.CreatedAt, value)
+	return err
+}
+
+func getPipeline(q querier, uuid string) (*model.Pipeline, error) {
+	var data []byte
+	err := q.QueryRow(`SELECT data FROM pipelines WHERE uuid = $1`, uuid).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "pipeline"}
+		}
+		return nil, err
+	}
+	var p *model.Pipeline
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	p.Jobs, err = getJobsByPipelineID(q, uuid)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CreatePipeline adds a new pipeline and of its jobs to the storage.
+func (inst *Postgres) CreatePipeline(p *model.Pipeline) error {
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	return inst.withTx(func(tx *sql.Tx) error {
+		for i, j := range p.Jobs {
+			if err := putJob(tx, j, i); err != nil {
+				return err
+			}
+		}
+		return putPipeline(tx, p.UUID, p)
+	})
+}
+
+// GetPipeline fetches a pipeline from the storage.
+func (inst *Postgres) GetPipeline(uuid string) (*model.Pipeline, error) {
+	return getPipeline(inst.db, uuid)
+}
+
+// GetPipelines fetches all pipelines from the storage, optionally filters the pipelines by status.
+func (inst *Postgres) GetPipelines(status model.JobStatus) ([]*model.Pipeline, error) {
+	var rows *sql.Rows
+	var err error
+	if status == model.Undefined {
+		rows, err = inst.db.Query(`SELECT data FROM pipelines ORDER BY created_at ASC`)
+	} else {
+		rows, err = inst.db.Query(`SELECT data FROM pipelines WHERE status = $1 ORDER BY created_at ASC`, status.Index())
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pipelines []*model.Pipeline
+	err = scanAll(rows, func() interface{} {
+		p := &model.Pipeline{}
+		pipelines = append(pipelines, p)
+		return p
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
+
+// RecyclePipeline updates a pipeline to the storage.
+func (inst *Postgres) RecyclePipeline(id string, p *model.Pipeline) (*model.Pipeline, error) {
+	runAtUUID, _ := uuid.New().Make("run")
+	p.RunAtUUID = runAtUUID
+	var recycled *model.Pipeline
+	err := inst.withTx(func(tx *sql.Tx) error {
+		jobs, err := getJobsByPipelineID(tx, id) // get the existing pipeline jobs
+		if err != nil {
+			return err
+		}
+		runAts, err := automater.RecyclePipelineRunAts(p, jobs)
+		if err != nil {
+			return err
+		}
+		var recycleJobs []*model.Job
+		for i, j := range jobs {
+			j.RunAt = runAts[i]
+			recycleJob, err := recycle(tx, j.UUID, j) // recycle jobs
+			if err != nil {
+				return err
+			}
+			recycleJobs = append(recycleJobs, recycleJob)
+		}
+
+		p.Jobs = recycleJobs
+		p.Status = model.Pending
+		p.RunAt = nil
+		if len(recycleJobs) > 0 {
+			p.RunAt = recycleJobs[0].RunAt
+		}
+		p.StartedAt = nil
+		p.Duration = nil
+		p.RunVars = p.TriggerVars
+		if err := putPipeline(tx, id, p); err != nil {
+			return err
+		}
+		recycled, err = getPipeline(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recycled, nil
+}
+
+// UpdatePipeline updates a pipeline to the storage.
+func (inst *Postgres) UpdatePipeline(uuid string, p *model.Pipeline) error {
+	return putPipeline(inst.db, uuid, p)
+}
+
+// DeletePipeline deletes a pipeline and all its jobs from the storage.
+func (inst *Postgres) DeletePipeline(uuid string) error {
+	return inst.withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM job_results WHERE job_id IN (SELECT uuid FROM jobs WHERE pipeline_id = $1)`, uuid)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM jobs WHERE pipeline_id = $1`, uuid); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM pipelines WHERE uuid = $1`, uuid)
+		return err
+	})
+}
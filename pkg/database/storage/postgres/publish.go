@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"encoding/json"
+)
+
+// Pub posts the message to the channel with NOTIFY, listeners use LISTEN on the same channel.
+func (inst *Postgres) Pub(channel string, message interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = inst.db.Exec(`SELECT pg_notify($1, $2)`, channel, string(payload))
+	return err
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+)
+
+// CreateJobResult adds a new job result to the storage.
+func (inst *Postgres) CreateJobResult(result *model.JobResult) error {
+	return inst.UpdateJobResult(result.JobID, result)
+}
+
+// GetJobResult fetches a job result from the storage.
+func (inst *Postgres) GetJobResult(jobID string) (*model.JobResult, error) {
+	var data []byte
+	err := inst.db.QueryRow(`SELECT data FROM job_results WHERE job_id = $1`, jobID).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &apperrors.NotFoundErr{UUID: jobID, ResourceName: "job result"}
+		}
+		return nil, err
+	}
+	var result *model.JobResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateJobResult updates a job result to the storage.
+func (inst *Postgres) UpdateJobResult(jobID string, result *model.JobResult) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = inst.db.Exec(`
+		INSERT INTO job_results (job_id, data) VALUES ($1, $2)
+		ON CONFLICT (job_id) DO UPDATE SET data = EXCLUDED.data`, jobID, value)
+	return err
+}
+
+// DeleteJobResult deletes a job result from the storage.
+func (inst *Postgres) DeleteJobResult(jobID string) error {
+	_, err := inst.db.Exec(`DELETE FROM job_results WHERE job_id = $1`, jobID)
+	return err
+}
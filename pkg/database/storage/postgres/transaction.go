@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/NubeIO/rubix-automater/automater/model"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/apperrors"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/ttime"
+	"github.com/NubeIO/rubix-automater/pkg/helpers/uuid"
+)
+
+const automaterTransaction = "automater-transaction"
+
+// CreateTransaction adds a new trans result to the storage.
+func (inst *Postgres) CreateTransaction(job *model.Job) (*model.Transaction, error) {
+	id, _ := uuid.New().Make("tra")
+	now := ttime.New().Now()
+	isPipeLine := false
+	runAtUUID := "false"
+	if job.PipelineID != "" {
+		isPipeLine = true
+		getPipeline, err := getPipeline(inst.db, job.PipelineID)
+		if err != nil {
+			return nil, err
+		}
+		runAtUUID = getPipeline.RunAtUUID
+	}
+	trans := &model.Transaction{
+		UUID:          id,
+		PipelineID:    job.PipelineID,
+		JobID:         job.UUID,
+		TaskType:      job.TaskName,
+		SubTaskType:   job.SubTaskName,
+		IsPipeLine:    isPipeLine,
+		RunAtUUID:     runAtUUID,
+		Status:        job.Status,
+		Attempt:       job.Attempt,
+		FailureReason: job.FailureReason,
+		StartedAt:     job.StartedAt,
+		CreatedAt:     &now,
+		CompletedAt:   job.CompletedAt,
+		Duration:      job.Duration,
+	}
+	value, err := json.Marshal(trans)
+	if err != nil {
+		return nil, err
+	}
+	_, err = inst.db.Exec(`
+		INSERT INTO transactions (uuid, job_id, status, created_at, data)
+		VALUES ($1, $2, $3, $4, $5)`,
+		id, job.UUID, trans.Status.Index(), trans.CreatedAt, value)
+	if err != nil {
+		return nil, err
+	}
+	tran, err := inst.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+
+	pubTrans := model.PublishTransaction{
+		UUID:          tran.UUID,
+		JobID:         job.UUID,
+		IsPipeLine:    isPipeLine,
+		PipelineID:    job.PipelineID,
+		TaskType:      tran.TaskType,
+		SubTaskType:   tran.SubTaskType,
+		Status:        tran.Status.String(),
+		Attempt:       tran.Attempt,
+		RunAtUUID:     tran.RunAtUUID,
+		FailureReason: tran.FailureReason,
+		CreatedAt:     tran.CreatedAt,
+		StartedAt:     tran.StartedAt,
+		CompletedAt:   tran.CompletedAt,
+		Duration:      tran.Duration,
+	}
+	if err := inst.Pub(automaterTransaction, pubTrans); err != nil {
+		return nil, err
+	}
+	return tran, nil
+}
+
+// DeleteTransaction deletes a trans from the storage.
+func (inst *Postgres) DeleteTransaction(uuid string) error {
+	_, err := inst.db.Exec(`DELETE FROM transactions WHERE uuid = $1`, uuid)
+	return err
+}
+
+func (inst *Postgres) queryTransactions(query string, args ...interface{}) ([]*model.Transaction, error) {
+	rows, err := inst.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var transactions []*model.Transaction
+	err = scanAll(rows, func() interface{} {
+		t := &model.Transaction{}
+		transactions = append(transactions, t)
+		return t
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetTransactions fetches all trans from the storage, optionally filters the jobs by status.
+func (inst *Postgres) GetTransactions(status model.JobStatus) ([]*model.Transaction, error) {
+	if status == model.Undefined {
+		return inst.queryTransactions(`SELECT data FROM transactions ORDER BY created_at ASC`)
+	}
+	return inst.queryTransactions(`SELECT data FROM transactions WHERE status = $1 ORDER BY created_at ASC`, status.Index())
+}
+
+// GetTransactionsByJob fetches all trans from the storage by job
+func (inst *Postgres) GetTransactionsByJob(jobId string) ([]*model.Transaction, error) {
+	return inst.queryTransactions(`SELECT data FROM transactions WHERE job_id = $1 ORDER BY created_at ASC`, jobId)
+}
+
+// GetTransaction fetches a trans from the storage.
+func (inst *Postgres) GetTransaction(uuid string) (*model.Transaction, error) {
+	var data []byte
+	err := inst.db.QueryRow(`SELECT data FROM transactions WHERE uuid = $1`, uuid).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &apperrors.NotFoundErr{UUID: uuid, ResourceName: "transactionctl"}
+		}
+		return nil, err
+	}
+	var t *model.Transaction
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
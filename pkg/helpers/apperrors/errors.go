@@ -5,6 +5,7 @@ import (
 )
 
 var _ error = &FullQueueErr{}
+var _ error = &ClosedQueueErr{}
 var _ error = &NotFoundErr{}
 var _ error = &FullWorkerPoolBacklog{}
 var _ error = &ResourceValidationErr{}
@@ -17,6 +18,13 @@ func (e *FullQueueErr) Error() string {
 
 }
 
+// ClosedQueueErr is an error to indicate that a queue is closed, eg: the automater is shutting down.
+type ClosedQueueErr struct{}
+
+func (e *ClosedQueueErr) Error() string {
+	return "job queue is closed"
+}
+
 // NotFoundErr is an error indicating an resource is not found.
 type NotFoundErr struct {
 	UUID         string
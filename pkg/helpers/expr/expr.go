@@ -0,0 +1,464 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Supported expressions
+//	- paths into the evaluated values, eg: steps.ping.result.ok or steps.ping.result.hosts.0 for the first item of a list
+//	- literals: true, false, null, numbers and 'single' or "double" quoted strings
+//	- comparisons: == != < <= > >=, numbers compare as numbers and strings as strings
+//	- logic: && || ! and parentheses, a path on its own must be a boolean (or missing, which is false)
+
+// Expr is a parsed boolean expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a boolean expression.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != eof {
+		return nil, fmt.Errorf("unexpected %s at %d in %s", p.tok, p.tok.pos, src)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Validate checks that the expression can be parsed.
+func Validate(src string) error {
+	_, err := Parse(src)
+	return err
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Paths returns the paths the expression looks up, in order of appearance.
+func (e *Expr) Paths() []string {
+	var paths []string
+	e.root.paths(&paths)
+	return paths
+}
+
+// Eval evaluates the expression against the values.
+func (e *Expr) Eval(values map[string]interface{}) (bool, error) {
+	v, err := e.root.eval(values)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", e.src, err)
+	}
+	b, err := truth(v)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", e.src, err)
+	}
+	return b, nil
+}
+
+// Lookup returns the value at the dotted path, or nil if there's none. Structs are looked up by their JSON fields.
+func Lookup(values interface{}, path string) interface{} {
+	v := values
+	for _, key := range strings.Split(path, ".") {
+		v = child(v, key)
+		if v == nil {
+			return nil
+		}
+	}
+	return v
+}
+
+func child(v interface{}, key string) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return value[key]
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(value) {
+			return nil
+		}
+		return value[i]
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		// Look it up the way it's stored.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil
+		}
+		if reflect.TypeOf(decoded) == reflect.TypeOf(v) {
+			return nil
+		}
+		return child(decoded, key)
+	}
+	return nil
+}
+
+type node interface {
+	eval(values map[string]interface{}) (interface{}, error)
+	paths(paths *[]string)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n literal) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n literal) paths(*[]string) {}
+
+type path struct {
+	path string
+}
+
+func (n path) eval(values map[string]interface{}) (interface{}, error) {
+	return Lookup(values, n.path), nil
+}
+
+func (n path) paths(paths *[]string) {
+	*paths = append(*paths, n.path)
+}
+
+type not struct {
+	x node
+}
+
+func (n not) eval(values map[string]interface{}) (interface{}, error) {
+	v, err := n.x.eval(values)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truth(v)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+func (n not) paths(paths *[]string) {
+	n.x.paths(paths)
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (n binary) eval(values map[string]interface{}) (interface{}, error) {
+	x, err := n.x.eval(values)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		b, err := truth(x)
+		if err != nil {
+			return nil, err
+		}
+		if b == (n.op == "||") {
+			return b, nil
+		}
+		y, err := n.y.eval(values)
+		if err != nil {
+			return nil, err
+		}
+		return truth(y)
+	}
+	y, err := n.y.eval(values)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	}
+	c, err := compare(x, y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func (n binary) paths(paths *[]string) {
+	n.x.paths(paths)
+	n.y.paths(paths)
+}
+
+func truth(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("%v is not a boolean", v)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float32, float64:
+		return reflect.ValueOf(n).Float(), true
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(n).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(n).Uint()), true
+	}
+	return 0, false
+}
+
+func equal(x, y interface{}) bool {
+	if a, ok := number(x); ok {
+		b, ok := number(y)
+		return ok && a == b
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func compare(x, y interface{}) (int, error) {
+	if a, ok := number(x); ok {
+		if b, ok := number(y); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if a, ok := x.(string); ok {
+		if b, ok := y.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	}
+	return 0, fmt.Errorf("can not compare %v with %v", x, y)
+}
+
+const (
+	eof = iota
+	ident
+	num
+	str
+	op
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == eof {
+		return "end"
+	}
+	return strconv.Quote(t.text)
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// next reads the next token.
+func (p *parser) next() error {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: eof, pos: start}
+		return nil
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		for p.pos < len(p.src) && (isIdent(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: ident, text: p.src[start:p.pos], pos: start}
+	case isDigit(c) || c == '-':
+		p.pos++
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: num, text: p.src[start:p.pos], pos: start}
+	case c == '"' || c == '\'':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != c {
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			return fmt.Errorf("unterminated string at %d in %s", start, p.src)
+		}
+		p.pos++
+		p.tok = token{kind: str, text: p.src[start+1 : p.pos-1], pos: start}
+	default:
+		for _, o := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+			if strings.HasPrefix(p.src[p.pos:], o) {
+				p.pos += len(o)
+				p.tok = token{kind: op, text: o, pos: start}
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected %q at %d in %s", c, start, p.src)
+	}
+	return nil
+}
+
+func (p *parser) is(o string) bool {
+	return p.tok.kind == op && p.tok.text == o
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = binary{op: "||", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = binary{op: "&&", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.is("!") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{x: x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.is(o) {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			y, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			return binary{op: o, x: x, y: y}, nil
+		}
+	}
+	return x, nil
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+	if p.is("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, fmt.Errorf("expected \")\" at %d in %s, got %s", p.tok.pos, p.src, p.tok)
+		}
+		return x, p.next()
+	}
+	var n node
+	switch tok.kind {
+	case ident:
+		switch tok.text {
+		case "true":
+			n = literal{value: true}
+		case "false":
+			n = literal{value: false}
+		case "null", "nil":
+			n = literal{value: nil}
+		default:
+			n = path{path: tok.text}
+		}
+	case num:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid number at %d in %s", tok.text, tok.pos, p.src)
+		}
+		n = literal{value: f}
+	case str:
+		n = literal{value: tok.text}
+	default:
+		return nil, fmt.Errorf("unexpected %s at %d in %s", tok, tok.pos, p.src)
+	}
+	return n, p.next()
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '-' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,93 @@
+package expr
+
+import "testing"
+
+func TestEval(t *testing.T) {
+	values := map[string]interface{}{
+		"steps": map[string]interface{}{
+			"ping": map[string]interface{}{
+				"status": "COMPLETED",
+				"result": struct {
+					Ok      bool     `json:"ok"`
+					Latency int      `json:"latency"`
+					Hosts   []string `json:"hosts"`
+				}{Ok: false, Latency: 120, Hosts: []string{"10.0.0.1"}},
+			},
+		},
+	}
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"steps.ping.result.ok == false", true},
+		{"!steps.ping.result.ok", true},
+		{"steps.ping.status == 'COMPLETED' && steps.ping.result.latency > 100", true},
+		{"steps.ping.result.latency <= 100 || steps.ping.result.hosts.0 == \"10.0.0.1\"", true},
+		{"(steps.ping.result.ok || steps.ping.result.latency < -1) && true", false},
+		{"steps.ping.result.missing", false},
+		{"steps.other.status != null", false},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.expr)
+		if err != nil {
+			t.Fatalf("%s: %s", test.expr, err)
+		}
+		actual, err := e.Eval(values)
+		if err != nil {
+			t.Fatalf("%s: %s", test.expr, err)
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.expr, test.expected, actual)
+		}
+	}
+	for _, invalid := range []string{"", "steps.ping.result.ok ==", "(true", "'open", "a = b"} {
+		if err := Validate(invalid); err == nil {
+			t.Errorf("expected %q to fail", invalid)
+		}
+	}
+	if _, err := Parse("steps.ping.status < 1"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := Parse("steps.ping.status < 1")
+	if _, err := e.Eval(values); err == nil {
+		t.Error("expected a string to not compare with a number")
+	}
+	if paths := e.Paths(); len(paths) != 1 || paths[0] != "steps.ping.status" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
+
+func TestRender(t *testing.T) {
+	params := map[string]interface{}{
+		"host":    "{{ steps.lookup.result.host_uuid }}",
+		"port":    "{{vars.port}}",
+		"message": "install v{{ vars.version }} on {{ steps.lookup.result.host_uuid }}",
+		"tags":    []interface{}{"{{ vars.version }}", 1},
+	}
+	values := map[string]interface{}{
+		"steps": map[string]interface{}{"lookup": map[string]interface{}{"result": map[string]interface{}{"host_uuid": "hst_1"}}},
+		"vars":  map[string]interface{}{"version": "1.2.0", "port": 1660},
+	}
+	paths, err := Templates(params)
+	if err != nil || len(paths) != 5 {
+		t.Fatalf("expected 5 templates, got %v (%v)", paths, err)
+	}
+	rendered, err := Render(params, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := rendered.(map[string]interface{})
+	if actual["host"] != "hst_1" || actual["port"] != 1660 || actual["message"] != "install v1.2.0 on hst_1" ||
+		actual["tags"].([]interface{})[0] != "1.2.0" {
+		t.Fatalf("unexpected rendered params %v", actual)
+	}
+	if params["host"] != "{{ steps.lookup.result.host_uuid }}" {
+		t.Fatal("expected the params to be left as is")
+	}
+	if _, err := Render(map[string]interface{}{"v": "{{ vars.missing }}"}, values); err == nil {
+		t.Error("expected a template without value to fail")
+	}
+	if _, err := Templates(map[string]interface{}{"v": "{{ vars..version }}"}); err == nil {
+		t.Error("expected an invalid path to fail")
+	}
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Templates are dotted paths between double braces, eg: {{ vars.version }}, in the strings of a value, its nested maps
+// and lists included. A string made of a single template takes the value of the path as is, a number or a map for
+// instance, otherwise the values are formatted into the string.
+
+var (
+	templateRegexp = regexp.MustCompile(`{{\s*(.*?)\s*}}`)
+	pathRegexp     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\.[A-Za-z0-9_-]+)*$`)
+)
+
+// Templates returns the paths of the templates of the value, in order of appearance.
+func Templates(v interface{}) ([]string, error) {
+	var paths []string
+	err := walk(v, func(s string) (interface{}, error) {
+		for _, match := range templateRegexp.FindAllStringSubmatch(s, -1) {
+			if !pathRegexp.MatchString(match[1]) {
+				return nil, fmt.Errorf("%s is not a valid template path", match[0])
+			}
+			paths = append(paths, match[1])
+		}
+		return s, nil
+	}, nil)
+	return paths, err
+}
+
+// Render returns a copy of the value with its templates resolved against the values, a path without value is an error.
+func Render(v interface{}, values map[string]interface{}) (interface{}, error) {
+	var rendered interface{}
+	err := walk(v, func(s string) (interface{}, error) {
+		matches := templateRegexp.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+			return lookupTemplate(s, s[matches[0][2]:matches[0][3]], values)
+		}
+		var err error
+		result := templateRegexp.ReplaceAllStringFunc(s, func(match string) string {
+			if err != nil {
+				return match
+			}
+			var value interface{}
+			value, err = lookupTemplate(match, templateRegexp.FindStringSubmatch(match)[1], values)
+			if err != nil {
+				return match
+			}
+			return format(value)
+		})
+		return result, err
+	}, &rendered)
+	return rendered, err
+}
+
+// HasTemplates checks if the value has any template.
+func HasTemplates(v interface{}) bool {
+	found := false
+	_ = walk(v, func(s string) (interface{}, error) {
+		found = found || templateRegexp.MatchString(s)
+		return s, nil
+	}, nil)
+	return found
+}
+
+func lookupTemplate(template, path string, values map[string]interface{}) (interface{}, error) {
+	value := Lookup(values, path)
+	if value == nil {
+		return nil, fmt.Errorf("%s has no value", template)
+	}
+	return value, nil
+}
+
+func format(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// walk calls fn on every string of the value, the copy with the strings fn returned is set to out if it's not nil.
+func walk(v interface{}, fn func(s string) (interface{}, error), out *interface{}) error {
+	var result interface{}
+	switch value := v.(type) {
+	case string:
+		s, err := fn(value)
+		if err != nil {
+			return err
+		}
+		result = s
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			var rendered interface{}
+			if err := walk(item, fn, &rendered); err != nil {
+				return err
+			}
+			m[k] = rendered
+		}
+		result = m
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, item := range value {
+			if err := walk(item, fn, &l[i]); err != nil {
+				return err
+			}
+		}
+		result = l
+	default:
+		result = v
+	}
+	if out != nil {
+		*out = result
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"time"
+	// Embed the IANA database, the devices running the automater don't always ship one.
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Supported cron expressions
+//	- standard 5 fields: minute hour day-of-month month day-of-week, eg: */15 * * * *
+//	- 6 fields with leading seconds, eg: 0 */15 * * * *
+//	- descriptors: @yearly, @monthly, @weekly, @daily, @midnight, @hourly and @every <duration>
+
+var parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Parse parses a cron expression.
+func Parse(expr string) (cron.Schedule, error) {
+	return parser.Parse(expr)
+}
+
+// Validate checks that the cron expression can be parsed.
+func Validate(expr string) error {
+	_, err := Parse(expr)
+	return err
+}
+
+// Next returns the first activation of the cron expression after the given time.
+func Next(expr string, after time.Time) (time.Time, error) {
+	s, err := Parse(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(after), nil
+}
+
+// LoadLocation returns the IANA timezone, eg: Australia/Sydney, an empty name is the local timezone of the host.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// In returns the time in the IANA timezone, it's returned as is if the timezone is invalid.
+func In(t time.Time, name string) time.Time {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
@@ -0,0 +1,30 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	after := time.Date(2022, 6, 4, 2, 23, 0, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2022, 6, 4, 2, 30, 0, 0, time.UTC)},
+		{"30 */15 * * * *", time.Date(2022, 6, 4, 2, 30, 30, 0, time.UTC)},
+		{"@daily", time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		next, err := Next(test.expr, after)
+		if err != nil {
+			t.Fatalf("%s: %s", test.expr, err)
+		}
+		if !next.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.expr, test.expected, next)
+		}
+	}
+	if err := Validate("61 * * * *"); err == nil {
+		t.Error("expected an invalid minute to fail")
+	}
+}
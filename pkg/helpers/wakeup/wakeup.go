@@ -0,0 +1,93 @@
+package wakeup
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Wakeup collects the due times and job queue pushes notified by the services, so the scheduler sleeps until the
+// earliest due time instead of polling the storage and the job queue.
+type Wakeup struct {
+	mu  sync.Mutex
+	due dueHeap
+	// changed signals that the earliest due time moved earlier.
+	changed chan struct{}
+	// queued signals that some work got pushed to the job queue.
+	queued chan struct{}
+}
+
+// New returns a Wakeup without any due time.
+func New() *Wakeup {
+	return &Wakeup{
+		changed: make(chan struct{}, 1),
+		queued:  make(chan struct{}, 1),
+	}
+}
+
+// NotifyDue tells that some work is due at runAt.
+func (w *Wakeup) NotifyDue(runAt time.Time) {
+	w.mu.Lock()
+	earliest := len(w.due) == 0 || runAt.Before(w.due[0])
+	heap.Push(&w.due, runAt)
+	w.mu.Unlock()
+	if earliest {
+		signal(w.changed)
+	}
+}
+
+// NotifyQueued tells that some work got pushed to the job queue.
+func (w *Wakeup) NotifyQueued() {
+	signal(w.queued)
+}
+
+// Changed is signaled when the earliest due time moved earlier.
+func (w *Wakeup) Changed() <-chan struct{} {
+	return w.changed
+}
+
+// Queued is signaled when some work got pushed to the job queue.
+func (w *Wakeup) Queued() <-chan struct{} {
+	return w.queued
+}
+
+// Next returns the earliest due time.
+func (w *Wakeup) Next() (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.due) == 0 {
+		return time.Time{}, false
+	}
+	return w.due[0], true
+}
+
+// PopDue drops the due times up to now, they're handled by the next pass of the scheduler.
+func (w *Wakeup) PopDue(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.due) > 0 && !w.due[0].After(now) {
+		heap.Pop(&w.due)
+	}
+}
+
+// signal wakes the receiver up unless a signal is already pending.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// dueHeap is a min-heap of due times.
+type dueHeap []time.Time
+
+func (h dueHeap) Len() int            { return len(h) }
+func (h dueHeap) Less(i, j int) bool  { return h[i].Before(h[j]) }
+func (h dueHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *dueHeap) Push(x interface{}) { *h = append(*h, x.(time.Time)) }
+func (h *dueHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
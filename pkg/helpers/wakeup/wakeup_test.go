@@ -0,0 +1,45 @@
+package wakeup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWakeup(t *testing.T) {
+	w := New()
+	if _, ok := w.Next(); ok {
+		t.Fatal("expected no due time")
+	}
+	now := time.Now()
+	w.NotifyDue(now.Add(time.Minute))
+	select {
+	case <-w.Changed():
+	default:
+		t.Fatal("expected the first due time to signal a change")
+	}
+	w.NotifyDue(now.Add(2 * time.Minute))
+	select {
+	case <-w.Changed():
+		t.Fatal("expected a later due time not to signal a change")
+	default:
+	}
+	w.NotifyDue(now.Add(-time.Second))
+	w.NotifyDue(now)
+	if next, _ := w.Next(); !next.Equal(now.Add(-time.Second)) {
+		t.Fatalf("expected the earliest due time, got %s", next)
+	}
+
+	w.PopDue(now)
+	if next, _ := w.Next(); !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the due times up to now to be dropped, got %s", next)
+	}
+
+	w.NotifyQueued()
+	w.NotifyQueued()
+	<-w.Queued()
+	select {
+	case <-w.Queued():
+		t.Fatal("expected the pushes to be signaled once")
+	default:
+	}
+}
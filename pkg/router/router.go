@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"github.com/NubeIO/rubix-automater/automater"
 	admin "github.com/NubeIO/rubix-automater/controller/adminctl"
+	"github.com/NubeIO/rubix-automater/controller/blackoutctl"
+	"github.com/NubeIO/rubix-automater/controller/deadletterctl"
 	"github.com/NubeIO/rubix-automater/controller/jobctl"
 	"github.com/NubeIO/rubix-automater/controller/pipectl"
 	"github.com/NubeIO/rubix-automater/controller/resultctl"
+	"github.com/NubeIO/rubix-automater/controller/schedulectl"
 	"github.com/NubeIO/rubix-automater/controller/taskctl"
 	"github.com/NubeIO/rubix-automater/controller/transactionctl"
 	"net/http"
@@ -24,6 +27,9 @@ func NewRouter(
 	resultService automater.ResultService,
 	pipelineService automater.PipelineService,
 	taskService automater.TaskService,
+	deadLetterService automater.DeadLetterService,
+	blackoutService automater.BlackoutService,
+	pauseService automater.PauseService,
 	jobQueue automater.JobQueue,
 	storage automater.Storage, loggingFormat string) *gin.Engine {
 
@@ -32,7 +38,10 @@ func NewRouter(
 	pipelineHandler := pipectl.NewPipelineHTTPHandler(pipelineService, jobQueue)
 	taskHandler := taskctl.NewTaskHTTPHandler(taskService)
 	transactionHandler := transactionctl.NewTransactionHTTPHandler(storage)
-	adminHandler := admin.NewAdminHTTPHandler(storage)
+	adminHandler := admin.NewAdminHTTPHandler(storage, pauseService)
+	deadLetterHandler := deadletterctl.NewDeadLetterHTTPHandler(deadLetterService)
+	scheduleHandler := schedulectl.NewScheduleHTTPHandler(jobService)
+	blackoutHandler := blackoutctl.NewBlackoutHTTPHandler(blackoutService)
 
 	r := gin.New()
 	if loggingFormat == "text" {
@@ -49,7 +58,7 @@ func NewRouter(
 	// CORS: Allow all origins - Revisit this.
 	r.Use(cors.Default())
 
-	r.GET("/api/status", HandleStatus(jobQueue, storage))
+	r.GET("/api/status", HandleStatus(jobQueue, storage, pauseService))
 
 	r.POST("/api/jobs", jobHandler.Create)
 	r.GET("/api/jobs", jobHandler.GetJobs)
@@ -57,8 +66,12 @@ func NewRouter(
 	r.PATCH("/api/jobs/:uuid", jobHandler.Update)
 	r.PATCH("/api/jobs/recycle/:uuid", jobHandler.Recycle)
 	r.DELETE("/api/jobs/:uuid", jobHandler.Delete)
+	r.POST("/api/jobs/:uuid/cancel", jobHandler.Cancel)
 	r.DELETE("/api/jobs/drop", jobHandler.Drop)
 
+	r.GET("/api/jobs/:uuid/schedule", scheduleHandler.GetJobSchedule)
+	r.POST("/api/schedules/preview", scheduleHandler.Preview)
+
 	r.GET("/api/jobs/:uuid/results", resultHandler.Get)
 	r.DELETE("/api/jobs/:uuid/results", resultHandler.Delete)
 
@@ -70,18 +83,37 @@ func NewRouter(
 	r.PATCH("/api/pipelines/:uuid", pipelineHandler.Update)
 	r.PATCH("/api/pipelines/recycle/:uuid", pipelineHandler.RecyclePipeline)
 	r.DELETE("/api/pipelines/:uuid", pipelineHandler.Delete)
+	r.POST("/api/pipelines/:uuid/cancel", pipelineHandler.Cancel)
 
 	r.GET("/api/pipelines/:uuid/jobs", pipelineHandler.GetPipelineJobs)
 
 	r.GET("/api/tasks", taskHandler.GetTasks)
 
+	r.GET("/api/dead-letters", deadLetterHandler.GetDeadLetters)
+	r.GET("/api/dead-letters/:uuid", deadLetterHandler.Get)
+	r.POST("/api/dead-letters/:uuid/requeue", deadLetterHandler.Requeue)
+	r.DELETE("/api/dead-letters/:uuid", deadLetterHandler.Delete)
+	r.DELETE("/api/dead-letters", deadLetterHandler.Purge)
+
+	r.POST("/api/blackouts", blackoutHandler.Create)
+	r.GET("/api/blackouts", blackoutHandler.GetBlackouts)
+	r.GET("/api/blackouts/:uuid", blackoutHandler.Get)
+	r.PATCH("/api/blackouts/:uuid", blackoutHandler.Update)
+	r.DELETE("/api/blackouts/:uuid", blackoutHandler.Delete)
+
 	r.DELETE("/api/admin/flush", adminHandler.WipeDB)
+	r.POST("/api/admin/pause", adminHandler.Pause)
+	r.POST("/api/admin/resume", adminHandler.Resume)
+	r.POST("/api/admin/queues/:queue/pause", adminHandler.Pause)
+	r.POST("/api/admin/queues/:queue/resume", adminHandler.Resume)
 
 	return r
 }
 
 // HandleStatus is an endpoint providing information and the status of the server,
-func HandleStatus(jobQueue automater.JobQueue, storage automater.Storage) gin.HandlerFunc {
+func HandleStatus(
+	jobQueue automater.JobQueue, storage automater.Storage, pauseService automater.PauseService) gin.HandlerFunc {
+
 	return func(c *gin.Context) {
 		now := time.Now().UTC()
 		res := map[string]interface{}{
@@ -89,6 +121,10 @@ func HandleStatus(jobQueue automater.JobQueue, storage automater.Storage) gin.Ha
 			"storage_healthy":   storage.CheckHealth(),
 			"ttime":             now,
 		}
+		// The paused state is left out if the storage is down, the storage_healthy flag tells why.
+		if p, err := pauseService.Get(); err == nil {
+			res["paused"] = p
+		}
 		c.JSON(http.StatusOK, res)
 	}
 }